@@ -8,7 +8,7 @@ tool (
 	github.com/client9/misspell/cmd/misspell
 	github.com/golangci/golangci-lint/cmd/golangci-lint
 	github.com/goreleaser/goreleaser/v2
-	github.com/traefik/yaegi
+	github.com/traefik/yaegi/cmd/yaegi
 	golang.org/x/vuln/cmd/govulncheck
 )
 
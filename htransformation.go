@@ -7,32 +7,65 @@ import (
 	"net"
 	"net/http"
 
+	"github.com/tomMoulard/htransformation/pkg/condition"
 	"github.com/tomMoulard/htransformation/pkg/handler/add"
+	"github.com/tomMoulard/htransformation/pkg/handler/basicauth"
+	"github.com/tomMoulard/htransformation/pkg/handler/clientcert"
+	"github.com/tomMoulard/htransformation/pkg/handler/cookietoheader"
 	"github.com/tomMoulard/htransformation/pkg/handler/deleter"
+	"github.com/tomMoulard/htransformation/pkg/handler/extract"
+	"github.com/tomMoulard/htransformation/pkg/handler/headertoquery"
 	"github.com/tomMoulard/htransformation/pkg/handler/join"
+	"github.com/tomMoulard/htransformation/pkg/handler/jwtclaims"
+	"github.com/tomMoulard/htransformation/pkg/handler/mapping"
+	"github.com/tomMoulard/htransformation/pkg/handler/messagesignature"
+	"github.com/tomMoulard/htransformation/pkg/handler/querytoheader"
 	"github.com/tomMoulard/htransformation/pkg/handler/rename"
+	"github.com/tomMoulard/htransformation/pkg/handler/requestid"
 	"github.com/tomMoulard/htransformation/pkg/handler/rewrite"
 	"github.com/tomMoulard/htransformation/pkg/handler/set"
+	"github.com/tomMoulard/htransformation/pkg/handler/sign"
+	"github.com/tomMoulard/htransformation/pkg/handler/sigv4"
+	"github.com/tomMoulard/htransformation/pkg/handler/verify"
+	"github.com/tomMoulard/htransformation/pkg/handler/xfcc"
+	"github.com/tomMoulard/htransformation/pkg/handler/xfcctoheaders"
 	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/clientip"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
 )
 
 // HeadersTransformation holds the necessary components of a Traefik plugin.
 type HeadersTransformation struct {
 	name         string
 	next         http.Handler
-	reqHandlers  []types.Handler
-	respHandlers []types.Handler
+	reqHandlers  []ruleHandler
+	respHandlers []ruleHandler
+	// saveOriginal is set when a rule reads the original: headers, which are
+	// then saved before the rules are applied.
+	saveOriginal bool
+}
+
+// ruleHandler is the handler of a rule along with the condition restricting it.
+type ruleHandler struct {
+	handler   types.Handler
+	condition condition.Condition
 }
 
 // Config holds configuration to be passed to the plugin.
 type Config struct {
 	Rules []types.Rule
+	// TrustedProxies lists the CIDRs of the proxies allowed to set ForwardedHeader.
+	TrustedProxies []string
+	// ForwardedHeader is the header written by the trusted proxies,
+	// X-Forwarded-For (default) or Forwarded.
+	ForwardedHeader string
 }
 
 // CreateConfig populates the Config data object.
 func CreateConfig() *Config {
 	return &Config{
-		Rules: []types.Rule{},
+		Rules:          []types.Rule{},
+		TrustedProxies: []string{},
 	}
 }
 
@@ -40,15 +73,36 @@ func CreateConfig() *Config {
 func New(_ context.Context, next http.Handler, config *Config, name string) (http.Handler, error) {
 	handlerBuilder := map[types.RuleType]func(types.Rule) (types.Handler, error){
 		types.Add:              add.New,
+		types.BasicAuth:        basicauth.New,
+		types.ClientCert:       clientcert.New,
+		types.CookieToHeader:   cookietoheader.New,
 		types.Delete:           deleter.New,
+		types.Extract:          extract.New,
+		types.HeaderToQuery:    headertoquery.New,
 		types.Join:             join.New,
+		types.JWTClaims:        jwtclaims.New,
+		types.Map:              mapping.New,
+		types.MessageSignature: messagesignature.New,
+		types.QueryToHeader:    querytoheader.New,
 		types.Rename:           rename.New,
+		types.RequestID:        requestid.New,
 		types.RewriteValueRule: rewrite.New,
 		types.Set:              set.New,
+		types.Sign:             sign.New,
+		types.SigV4:            sigv4.New,
+		types.Verify:           verify.New,
+		types.XFCC:             xfcc.New,
+		types.XFCCToHeaders:    xfcctoheaders.New,
+	}
+
+	resolver, err := clientip.NewResolver(config.TrustedProxies, config.ForwardedHeader)
+	if err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
 	}
 
-	reqHandlers := make([]types.Handler, 0, len(config.Rules))
-	respHandlers := make([]types.Handler, 0, len(config.Rules))
+	reqHandlers := make([]ruleHandler, 0, len(config.Rules))
+	respHandlers := make([]ruleHandler, 0, len(config.Rules))
+	saveOriginal := false
 
 	for _, rule := range config.Rules {
 		newHandler, ok := handlerBuilder[rule.Type]
@@ -56,6 +110,11 @@ func New(_ context.Context, next http.Handler, config *Config, name string) (htt
 			return nil, fmt.Errorf("%w: %s", types.ErrInvalidRuleType, rule.Name)
 		}
 
+		// Requests are answered with RejectStatus, which must be a valid status code.
+		if rule.RejectStatus != 0 && (rule.RejectStatus < 100 || rule.RejectStatus > 599) {
+			return nil, fmt.Errorf("%w: %d: %s", types.ErrInvalidRejectStatus, rule.RejectStatus, rule.Name)
+		}
+
 		handler, err := newHandler(rule)
 		if err != nil {
 			return nil, fmt.Errorf("%w: %s", err, rule.Name)
@@ -65,10 +124,17 @@ func New(_ context.Context, next http.Handler, config *Config, name string) (htt
 			return nil, fmt.Errorf("%w: %s", err, rule.Name)
 		}
 
+		cond, err := condition.New(rule, resolver)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, rule.Name)
+		}
+
+		saveOriginal = saveOriginal || readsOriginal(rule)
+
 		if rule.SetOnResponse {
-			respHandlers = append(respHandlers, handler)
+			respHandlers = append(respHandlers, ruleHandler{handler: handler, condition: cond})
 		} else {
-			reqHandlers = append(reqHandlers, handler)
+			reqHandlers = append(reqHandlers, ruleHandler{handler: handler, condition: cond})
 		}
 	}
 
@@ -77,32 +143,91 @@ func New(_ context.Context, next http.Handler, config *Config, name string) (htt
 		next:         next,
 		reqHandlers:  reqHandlers,
 		respHandlers: respHandlers,
+		saveOriginal: saveOriginal,
 	}, nil
 }
 
+// readsOriginal reports whether rule may read the original: headers, in its
+// values, templates, conditions, expression or source header.
+func readsOriginal(rule types.Rule) bool {
+	fields := []string{rule.Value, rule.ValueReplace, rule.From, rule.When}
+	fields = append(fields, rule.Values...)
+
+	for _, cond := range rule.If {
+		fields = append(fields, cond.Header)
+	}
+
+	for _, cond := range rule.Unless {
+		fields = append(fields, cond.Header)
+	}
+
+	for _, field := range fields {
+		if header.MentionsOriginal(field) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Iterate over every header to match the ones specified in the config and
 // return nothing if regexp failed.
 func (u *HeadersTransformation) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
-	for _, handler := range u.reqHandlers {
-		handler.Handle(responseWriter, request)
+	state := &types.State{}
+	if u.saveOriginal {
+		state.SaveOriginal(request)
+	}
+
+	request = types.WithState(request, state)
+
+	// responders are the request handlers that also act on the response.
+	var responders []types.ResponseHandler
+
+	for _, rh := range u.reqHandlers {
+		if !rh.condition.Match(responseWriter, request) {
+			continue
+		}
+
+		rh.handler.Handle(responseWriter, request)
+
+		if responder, ok := rh.handler.(types.ResponseHandler); ok {
+			responders = append(responders, responder)
+		}
+
+		if state.RejectStatus != 0 {
+			http.Error(responseWriter, http.StatusText(state.RejectStatus), state.RejectStatus)
+
+			return
+		}
 	}
 
-	wrappedResponseWriter := newWrappedResponseWriter(responseWriter, func(rw http.ResponseWriter) {
-		for _, handler := range u.respHandlers {
-			handler.Handle(rw, request)
+	wrappedResponseWriter := newWrappedResponseWriter(responseWriter, func(rw http.ResponseWriter, statusCode int) {
+		state.StatusCode = statusCode
+
+		for _, responder := range responders {
+			responder.HandleResponse(rw, request)
+		}
+
+		for _, rh := range u.respHandlers {
+			if rh.condition.Match(rw, request) {
+				rh.handler.Handle(rw, request)
+			}
 		}
 	})
 
 	u.next.ServeHTTP(wrappedResponseWriter, request)
+
+	// A backend that writes nothing sends an implied 200 OK response.
+	wrappedResponseWriter.handleResponseHeader(http.StatusOK)
 }
 
 type wrappedResponseWriter struct {
 	rw         http.ResponseWriter
-	handler    func(http.ResponseWriter)
+	handler    func(rw http.ResponseWriter, statusCode int)
 	headerSent bool
 }
 
-func newWrappedResponseWriter(rw http.ResponseWriter, handler func(http.ResponseWriter)) http.ResponseWriter {
+func newWrappedResponseWriter(rw http.ResponseWriter, handler func(http.ResponseWriter, int)) *wrappedResponseWriter {
 	return &wrappedResponseWriter{
 		rw:         rw,
 		handler:    handler,
@@ -110,13 +235,13 @@ func newWrappedResponseWriter(rw http.ResponseWriter, handler func(http.Response
 	}
 }
 
-func (wrw *wrappedResponseWriter) handleResponseHeader() {
+func (wrw *wrappedResponseWriter) handleResponseHeader(statusCode int) {
 	if wrw.headerSent {
 		return
 	}
 
 	wrw.headerSent = true
-	wrw.handler(wrw.rw)
+	wrw.handler(wrw.rw, statusCode)
 }
 
 func (wrw *wrappedResponseWriter) Header() http.Header {
@@ -124,7 +249,8 @@ func (wrw *wrappedResponseWriter) Header() http.Header {
 }
 
 func (wrw *wrappedResponseWriter) Write(p []byte) (int, error) {
-	wrw.handleResponseHeader()
+	// Writing without calling WriteHeader first implies a 200 OK response.
+	wrw.handleResponseHeader(http.StatusOK)
 
 	n, err := wrw.rw.Write(p)
 	if err != nil {
@@ -135,7 +261,16 @@ func (wrw *wrappedResponseWriter) Write(p []byte) (int, error) {
 }
 
 func (wrw *wrappedResponseWriter) WriteHeader(statusCode int) {
-	wrw.handleResponseHeader()
+	// Informational responses, such as 103 Early Hints, are followed by the
+	// final response, whose headers are the ones handled. 101 Switching
+	// Protocols is the final response of upgraded connections.
+	if statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols {
+		wrw.rw.WriteHeader(statusCode)
+
+		return
+	}
+
+	wrw.handleResponseHeader(statusCode)
 	wrw.rw.WriteHeader(statusCode)
 }
 
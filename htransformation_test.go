@@ -3,6 +3,8 @@ package htransformation_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
 	"testing"
 
 	plug "github.com/tomMoulard/htransformation"
@@ -59,6 +61,129 @@ func TestValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid condition expression",
+			config: &plug.Config{
+				Rules: []types.Rule{
+					{
+						Name:   "set rule",
+						Header: "not-empty",
+						Value:  "not-empty",
+						Type:   types.Set,
+						When:   `status == 200`,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid value template",
+			config: &plug.Config{
+				Rules: []types.Rule{
+					{
+						Name:        "set rule",
+						Header:      "not-empty",
+						Value:       "{{ .Method ",
+						Interpolate: true,
+						Type:        types.Set,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown filter",
+			config: &plug.Config{
+				Rules: []types.Rule{
+					{
+						Name:    "set rule",
+						Header:  "X-Test",
+						Value:   "Test",
+						Type:    types.Set,
+						Filters: []string{"reverse"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid map regexp",
+			config: &plug.Config{
+				Rules: []types.Rule{
+					{
+						Name:   "map rule",
+						From:   "X-Region",
+						Header: "X-Cluster",
+						Match:  types.MatchRegexp,
+						Table:  []types.Mapping{{Key: "(eu", Value: "fra-1"}},
+						Type:   types.Map,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "response header reference on request",
+			config: &plug.Config{
+				Rules: []types.Rule{
+					{
+						Name:         "join rule",
+						Header:       "X-Served-By",
+						Values:       []string{"^resp:Server"},
+						Sep:          ",",
+						HeaderPrefix: "^",
+						Type:         types.Join,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid extract regexp",
+			config: &plug.Config{
+				Rules: []types.Rule{
+					{
+						Name:   "extract rule",
+						From:   "Host",
+						Header: "X-Tenant",
+						Value:  "^([a-z]+\\.",
+						Type:   types.Extract,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing value reference",
+			config: &plug.Config{
+				Rules: []types.Rule{
+					{
+						Name:        "set rule",
+						Header:      "X-Api-Key",
+						Value:       "${file:/nonexistent/htransformation/key}",
+						Interpolate: true,
+						Type:        types.Set,
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid reject status",
+			config: &plug.Config{
+				Rules: []types.Rule{
+					{
+						Name:         "cookie rule",
+						Cookie:       "csrf",
+						Header:       "X-CSRF-Token",
+						OnFailure:    types.Reject,
+						RejectStatus: 42,
+						Type:         types.CookieToHeader,
+					},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid rule",
 			config: &plug.Config{
@@ -265,7 +390,11 @@ func TestAddMultipleHeaders(t *testing.T) {
 			cfg := plug.CreateConfig()
 			cfg.Rules = test.rules
 
+			var forwarded http.Header
+
 			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				forwarded = req.Header.Clone()
+
 				// Add initial response headers
 				for headerName, headerValues := range test.initialHeaders {
 					for _, headerValue := range headerValues {
@@ -290,12 +419,14 @@ func TestAddMultipleHeaders(t *testing.T) {
 
 			assert.Equal(t, http.StatusOK, statusCode)
 
+			// Request rules change the headers forwarded to the backend.
+			actual := resp.Header
+			if !test.rules[0].SetOnResponse {
+				actual = forwarded
+			}
+
 			for headerName, expectedValues := range test.expectedHeaders {
-				actualValues := resp.Header.Values(headerName)
-				assert.Equal(t, len(expectedValues), len(actualValues))
-				for i, expectedValue := range expectedValues {
-					assert.Equal(t, expectedValue, actualValues[i])
-				}
+				assert.Equal(t, expectedValues, actual.Values(headerName))
 			}
 		})
 	}
@@ -371,3 +502,746 @@ func TestSetOnResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestPathScopedRules(t *testing.T) {
+	t.Parallel()
+
+	rules := []types.Rule{
+		{
+			Name:      "api requests",
+			Header:    "X-Scope",
+			Value:     "api",
+			Type:      types.Set,
+			Path:      "/api/",
+			PathMatch: types.PathPrefix,
+		},
+		{
+			Name:          "api cache",
+			Header:        "Cache-Control",
+			Value:         "no-store",
+			Type:          types.Set,
+			SetOnResponse: true,
+			Path:          "/api/*",
+			PathMatch:     types.PathGlob,
+		},
+		{
+			Name:          "static cache",
+			Header:        "Cache-Control",
+			Value:         "max-age=3600",
+			Type:          types.Set,
+			SetOnResponse: true,
+			Path:          "/static/*",
+			PathMatch:     types.PathGlob,
+		},
+	}
+
+	testCases := []struct {
+		name                 string
+		path                 string
+		expectedScope        string
+		expectedCacheControl string
+	}{
+		{
+			name:                 "api path",
+			path:                 "/api/users",
+			expectedScope:        "api",
+			expectedCacheControl: "no-store",
+		},
+		{
+			name:                 "static path",
+			path:                 "/static/app.js",
+			expectedScope:        "",
+			expectedCacheControl: "max-age=3600",
+		},
+		{
+			name:                 "other path",
+			path:                 "/health",
+			expectedScope:        "",
+			expectedCacheControl: "",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := plug.CreateConfig()
+			cfg.Rules = rules
+
+			var scope string
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				scope = req.Header.Get("X-Scope")
+
+				rw.WriteHeader(http.StatusOK)
+			})
+
+			handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://localhost"+test.path, nil)
+			require.NoError(t, err)
+
+			handler.ServeHTTP(recorder, req)
+			resp := recorder.Result()
+			require.NoError(t, resp.Body.Close())
+
+			assert.Equal(t, test.expectedScope, scope)
+			assert.Equal(t, test.expectedCacheControl, resp.Header.Get("Cache-Control"))
+		})
+	}
+}
+
+func TestStatusConditions(t *testing.T) {
+	t.Parallel()
+
+	rules := []types.Rule{
+		{
+			Name:          "no store on errors",
+			Header:        "Cache-Control",
+			Value:         "no-store",
+			Type:          types.Set,
+			SetOnResponse: true,
+			Status:        []string{"4xx", "5xx"},
+		},
+		{
+			Name:          "retry after",
+			Header:        "Retry-After",
+			Value:         "120",
+			Type:          types.Set,
+			SetOnResponse: true,
+			Status:        []string{"503"},
+		},
+	}
+
+	testCases := []struct {
+		name                 string
+		statusCode           int
+		writeHeader          bool
+		expectedCacheControl string
+		expectedRetryAfter   string
+	}{
+		{
+			name:                 "service unavailable",
+			statusCode:           http.StatusServiceUnavailable,
+			writeHeader:          true,
+			expectedCacheControl: "no-store",
+			expectedRetryAfter:   "120",
+		},
+		{
+			name:                 "not found",
+			statusCode:           http.StatusNotFound,
+			writeHeader:          true,
+			expectedCacheControl: "no-store",
+		},
+		{
+			name:        "ok",
+			statusCode:  http.StatusOK,
+			writeHeader: true,
+		},
+		{
+			name:        "write without status defaults to ok",
+			statusCode:  http.StatusOK,
+			writeHeader: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := plug.CreateConfig()
+			cfg.Rules = rules
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+				if test.writeHeader {
+					rw.WriteHeader(test.statusCode)
+				}
+
+				_, _ = rw.Write([]byte("body"))
+			})
+
+			handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://localhost", nil)
+			require.NoError(t, err)
+
+			handler.ServeHTTP(recorder, req)
+			resp := recorder.Result()
+			require.NoError(t, resp.Body.Close())
+
+			assert.Equal(t, test.statusCode, resp.StatusCode)
+			assert.Equal(t, test.expectedCacheControl, resp.Header.Get("Cache-Control"))
+			assert.Equal(t, test.expectedRetryAfter, resp.Header.Get("Retry-After"))
+		})
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		trustedProxies  []string
+		forwardedHeader string
+		wantErr         bool
+		expectedDebug   string
+	}{
+		{
+			name:          "forwarded for ignored without trusted proxies",
+			expectedDebug: "",
+		},
+		{
+			name:           "forwarded for from trusted proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			expectedDebug:  "on",
+		},
+		{
+			name:            "forwarded header not written by trusted proxy",
+			trustedProxies:  []string{"10.0.0.0/8"},
+			forwardedHeader: "Forwarded",
+			expectedDebug:   "",
+		},
+		{
+			name:           "invalid trusted proxy",
+			trustedProxies: []string{"10.0.0.0/80"},
+			wantErr:        true,
+		},
+		{
+			name:            "invalid forwarded header",
+			trustedProxies:  []string{"10.0.0.0/8"},
+			forwardedHeader: "X-Real-IP",
+			wantErr:         true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := plug.CreateConfig()
+			cfg.TrustedProxies = test.trustedProxies
+			cfg.ForwardedHeader = test.forwardedHeader
+			cfg.Rules = []types.Rule{
+				{
+					Name:        "debug for office",
+					Header:      "X-Debug",
+					Value:       "on",
+					Type:        types.Set,
+					ClientCIDRs: []string{"192.168.0.0/16"},
+				},
+			}
+
+			var debug string
+
+			next := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				debug = req.Header.Get("X-Debug")
+			})
+
+			handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+			if test.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			req.RemoteAddr = "10.0.0.2:1234"
+			req.Header.Set("X-Forwarded-For", "192.168.1.10")
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, test.expectedDebug, debug)
+		})
+	}
+}
+
+func TestRejectedRequest(t *testing.T) {
+	t.Parallel()
+
+	cfg := plug.CreateConfig()
+	cfg.Rules = []types.Rule{
+		{
+			Name:         "csrf token",
+			Cookie:       "csrf",
+			Header:       "X-CSRF-Token",
+			Type:         types.CookieToHeader,
+			OnFailure:    types.Reject,
+			RejectStatus: http.StatusForbidden,
+		},
+		{
+			Name:          "response header",
+			Header:        "X-Handled",
+			Value:         "true",
+			Type:          types.Set,
+			SetOnResponse: true,
+		},
+	}
+
+	var forwarded bool
+
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		forwarded = true
+	})
+
+	handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://localhost", nil)
+	require.NoError(t, err)
+
+	handler.ServeHTTP(recorder, req)
+	resp := recorder.Result()
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, false, forwarded)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "", resp.Header.Get("X-Handled"))
+}
+
+func TestRequestIDEcho(t *testing.T) {
+	t.Parallel()
+
+	cfg := plug.CreateConfig()
+	cfg.Rules = []types.Rule{
+		{
+			Name:   "request id",
+			Type:   types.RequestID,
+			Format: types.ULID,
+		},
+		{
+			Name:          "copy request id",
+			Header:        "X-Seen-Id",
+			Value:         `{{ header "X-Request-Id" }}`,
+			Interpolate:   true,
+			Type:          types.Set,
+			SetOnResponse: true,
+		},
+	}
+
+	tests := []struct {
+		name      string
+		requestID string
+		// silent backends write no response, an implied 200 OK being sent.
+		silent bool
+	}{
+		{
+			name: "generated request id",
+		},
+		{
+			name:      "incoming request id",
+			requestID: "abc-123",
+		},
+		{
+			name:      "backend writing nothing",
+			requestID: "abc-123",
+			silent:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var forwardedID string
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				forwardedID = req.Header.Get("X-Request-Id")
+				if test.silent {
+					return
+				}
+
+				// The backend cannot change the echoed ID.
+				rw.Header().Set("X-Request-Id", "backend")
+				rw.WriteHeader(http.StatusNoContent)
+			})
+
+			handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://localhost", nil)
+			require.NoError(t, err)
+
+			if test.requestID != "" {
+				req.Header.Set("X-Request-Id", test.requestID)
+			}
+
+			handler.ServeHTTP(recorder, req)
+			resp := recorder.Result()
+			require.NoError(t, resp.Body.Close())
+
+			if test.requestID != "" {
+				assert.Equal(t, test.requestID, forwardedID)
+			} else {
+				assert.Equal(t, 26, len(forwardedID))
+			}
+
+			assert.Equal(t, forwardedID, resp.Header.Get("X-Request-Id"))
+			assert.Equal(t, forwardedID, resp.Header.Get("X-Seen-Id"))
+		})
+	}
+}
+
+func TestFilters(t *testing.T) {
+	t.Parallel()
+
+	cfg := plug.CreateConfig()
+	cfg.Rules = []types.Rule{
+		{
+			Name:    "tenant",
+			Type:    types.QueryToHeader,
+			Param:   "tenant",
+			Header:  "X-Tenant",
+			Filters: []string{"trim", "upper"},
+		},
+		{
+			Name:    "session hash",
+			Type:    types.CookieToHeader,
+			Cookie:  "session",
+			Header:  "X-Session-Hash",
+			Filters: []string{"sha256", "substring:0:12"},
+		},
+		{
+			Name:    "legacy",
+			Type:    types.Rename,
+			Header:  "X-Legacy-Id",
+			Value:   "X-Id",
+			Filters: []string{`regex:id-(\d+)`},
+		},
+		{
+			Name:    "forwarded",
+			Type:    types.Join,
+			Header:  "X-Path",
+			Values:  []string{"B"},
+			Sep:     "/",
+			Filters: []string{"lower"},
+		},
+		{
+			Name:    "user",
+			Type:    types.HeaderToQuery,
+			Header:  "X-User",
+			Param:   "user",
+			Filters: []string{"trimprefix:user:"},
+		},
+	}
+
+	var forwarded *http.Request
+
+	next := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		forwarded = req
+	})
+
+	handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://localhost/?tenant=%20acme%20", nil)
+	require.NoError(t, err)
+
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req.Header.Set("X-Legacy-Id", "id-42")
+	req.Header.Set("X-Path", "A")
+	req.Header.Set("X-User", "user:jane")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "ACME", forwarded.Header.Get("X-Tenant"))
+	assert.Equal(t, "ba7816bf8f01", forwarded.Header.Get("X-Session-Hash"))
+	assert.Equal(t, "42", forwarded.Header.Get("X-Id"))
+	assert.Equal(t, "a/b", forwarded.Header.Get("X-Path"))
+	assert.Equal(t, "jane", forwarded.URL.Query().Get("user"))
+}
+
+func TestOriginalHeaders(t *testing.T) {
+	t.Parallel()
+
+	cfg := plug.CreateConfig()
+	cfg.Rules = []types.Rule{
+		{
+			Name:   "rewrite host",
+			Type:   types.Set,
+			Header: "Host",
+			Value:  "backend.internal",
+		},
+		{
+			Name:   "overwrite tenant",
+			Type:   types.Set,
+			Header: "X-Tenant",
+			Value:  "internal",
+		},
+		{
+			Name:         "original host",
+			Type:         types.Set,
+			Header:       "X-Original-Host",
+			Value:        "^original:Host",
+			Interpolate:  true,
+			HeaderPrefix: "^",
+		},
+		{
+			Name:        "original tenant",
+			Type:        types.Set,
+			Header:      "X-Client-Tenant",
+			Value:       `{{ header "original:X-Tenant" }}`,
+			Interpolate: true,
+		},
+		{
+			Name:   "tenant sent by the client",
+			Type:   types.Set,
+			Header: "X-Tenant-Sent",
+			Value:  "true",
+			If:     []types.Condition{{Header: "original:X-Tenant"}},
+		},
+		{
+			Name:   "host rewritten",
+			Type:   types.Set,
+			Header: "X-Host-Rewritten",
+			Value:  "true",
+			When:   `header("original:Host") != host`,
+		},
+		{
+			Name:          "original host on response",
+			Type:          types.Set,
+			Header:        "X-Original-Host",
+			Value:         "^original:Host",
+			Interpolate:   true,
+			HeaderPrefix:  "^",
+			SetOnResponse: true,
+		},
+		{
+			Name:          "original tenant on response",
+			Type:          types.Extract,
+			From:          "original:X-Tenant",
+			Header:        "X-Tenant",
+			Value:         "^[a-z]+$",
+			SetOnResponse: true,
+		},
+	}
+
+	tests := []struct {
+		name                 string
+		tenant               string
+		expectedTenantSent   string
+		expectedClientTenant string
+	}{
+		{
+			name:                 "tenant sent",
+			tenant:               "acme",
+			expectedTenantSent:   "true",
+			expectedClientTenant: "acme",
+		},
+		{
+			name: "tenant not sent",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var forwarded *http.Request
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				forwarded = req
+
+				rw.WriteHeader(http.StatusNoContent)
+			})
+
+			handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+			require.NoError(t, err)
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/", nil)
+			require.NoError(t, err)
+
+			if test.tenant != "" {
+				req.Header.Set("X-Tenant", test.tenant)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, "backend.internal", forwarded.Host)
+			assert.Equal(t, "internal", forwarded.Header.Get("X-Tenant"))
+			assert.Equal(t, "example.com", forwarded.Header.Get("X-Original-Host"))
+			assert.Equal(t, test.expectedClientTenant, forwarded.Header.Get("X-Client-Tenant"))
+			assert.Equal(t, test.expectedTenantSent, forwarded.Header.Get("X-Tenant-Sent"))
+			assert.Equal(t, "true", forwarded.Header.Get("X-Host-Rewritten"))
+			assert.Equal(t, "example.com", recorder.Header().Get("X-Original-Host"))
+			assert.Equal(t, test.expectedClientTenant, recorder.Header().Get("X-Tenant"))
+		})
+	}
+}
+
+// TestOriginalHeaderSources checks that each way of reading the original:
+// headers makes them saved, when it is the only one in the configuration.
+func TestOriginalHeaderSources(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		rule types.Rule
+	}{
+		{
+			name: "header condition",
+			rule: types.Rule{
+				If: []types.Condition{{Header: "original:Host", Value: "example.com"}},
+			},
+		},
+		{
+			name: "expression",
+			rule: types.Rule{
+				When: `header("Original:Host") == "example.com"`,
+			},
+		},
+		{
+			name: "template",
+			rule: types.Rule{
+				Value:       `{{ if eq (header "original:Host") "example.com" }}true{{ end }}`,
+				Interpolate: true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule := test.rule
+			rule.Name = "original host"
+			rule.Type = types.Set
+			rule.Header = "X-From-Client"
+
+			if rule.Value == "" {
+				rule.Value = "true"
+			}
+
+			cfg := plug.CreateConfig()
+			cfg.Rules = []types.Rule{
+				{
+					Name:   "rewrite host",
+					Type:   types.Set,
+					Header: "Host",
+					Value:  "backend.internal",
+				},
+				rule,
+			}
+
+			var forwarded *http.Request
+
+			next := http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				forwarded = req
+			})
+
+			handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, "backend.internal", forwarded.Host)
+			assert.Equal(t, "true", forwarded.Header.Get("X-From-Client"))
+		})
+	}
+}
+
+func TestResponseHeaderReferences(t *testing.T) {
+	t.Parallel()
+
+	cfg := plug.CreateConfig()
+	cfg.Rules = []types.Rule{
+		{
+			Name:          "echo correlation id",
+			Type:          types.Add,
+			Header:        "X-Correlation-Id",
+			Value:         "^req:X-Correlation-Id",
+			HeaderPrefix:  "^",
+			SetOnResponse: true,
+		},
+		{
+			Name:          "version from etag",
+			Type:          types.Set,
+			Header:        "X-Version",
+			Value:         "^resp:ETag",
+			Interpolate:   true,
+			HeaderPrefix:  "^",
+			SetOnResponse: true,
+		},
+	}
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("ETag", `"33a64df5"`)
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://localhost/", nil)
+	require.NoError(t, err)
+
+	req.Header.Set("X-Correlation-Id", "abc-123")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, "abc-123", recorder.Header().Get("X-Correlation-Id"))
+	assert.Equal(t, `"33a64df5"`, recorder.Header().Get("X-Version"))
+}
+
+func TestInformationalResponses(t *testing.T) {
+	t.Parallel()
+
+	cfg := plug.CreateConfig()
+	cfg.Rules = []types.Rule{
+		{
+			Name:          "no store on errors",
+			Header:        "Cache-Control",
+			Value:         "no-store",
+			Type:          types.Set,
+			SetOnResponse: true,
+			Status:        []string{"5xx"},
+		},
+	}
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Link", "</style.css>; rel=preload; as=style")
+		rw.WriteHeader(http.StatusEarlyHints)
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	handler, err := plug.New(t.Context(), next, cfg, "demo-plugin")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	var informational []int
+
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, _ textproto.MIMEHeader) error {
+			informational = append(informational, code)
+
+			return nil
+		},
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(t.Context(), trace), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, []int{http.StatusEarlyHints}, informational)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+}
@@ -0,0 +1,39 @@
+package condition
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/clientip"
+)
+
+type cidrCondition struct {
+	nets     []*net.IPNet
+	resolver *clientip.Resolver
+}
+
+func newClientCIDR(resolver *clientip.Resolver) builder {
+	return func(rule types.Rule) ([]Condition, error) {
+		if len(rule.ClientCIDRs) == 0 {
+			return nil, nil
+		}
+
+		nets, err := clientip.ParseCIDRs(rule.ClientCIDRs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", types.ErrInvalidCondition, err)
+		}
+
+		return []Condition{&cidrCondition{nets: nets, resolver: resolver}}, nil
+	}
+}
+
+func (c *cidrCondition) Match(_ http.ResponseWriter, req *http.Request) bool {
+	ip := c.resolver.ClientIP(req)
+	if ip == nil {
+		return false
+	}
+
+	return clientip.Contains(c.nets, ip)
+}
@@ -0,0 +1,80 @@
+package condition_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/condition"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/clientip"
+)
+
+func TestClientCIDRCondition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		clientCIDRs  []string
+		remoteAddr   string
+		forwardedFor string
+		wantMatch    bool
+	}{
+		{
+			name:        "client in range",
+			clientCIDRs: []string{"192.168.0.0/16"},
+			remoteAddr:  "192.168.1.10:1234",
+			wantMatch:   true,
+		},
+		{
+			name:        "client out of range",
+			clientCIDRs: []string{"192.168.0.0/16"},
+			remoteAddr:  "203.0.113.7:1234",
+			wantMatch:   false,
+		},
+		{
+			name:         "client behind trusted proxy",
+			clientCIDRs:  []string{"192.168.0.0/16"},
+			remoteAddr:   "10.0.0.2:1234",
+			forwardedFor: "192.168.1.10",
+			wantMatch:    true,
+		},
+		{
+			name:         "spoofed forwarded for",
+			clientCIDRs:  []string{"192.168.0.0/16"},
+			remoteAddr:   "203.0.113.7:1234",
+			forwardedFor: "192.168.1.10",
+			wantMatch:    false,
+		},
+	}
+
+	resolver, err := clientip.NewResolver([]string{"10.0.0.0/8"}, "")
+	require.NoError(t, err)
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cond, err := condition.New(types.Rule{ClientCIDRs: test.clientCIDRs}, resolver)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+			req.RemoteAddr = test.remoteAddr
+
+			if test.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", test.forwardedFor)
+			}
+
+			assert.Equal(t, test.wantMatch, cond.Match(httptest.NewRecorder(), req))
+		})
+	}
+}
+
+func TestClientCIDRConditionValidation(t *testing.T) {
+	t.Parallel()
+
+	_, err := condition.New(types.Rule{ClientCIDRs: []string{"192.168.0.0/33"}}, nil)
+	assert.Error(t, err)
+}
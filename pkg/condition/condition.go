@@ -0,0 +1,56 @@
+package condition
+
+import (
+	"net/http"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/clientip"
+)
+
+// Condition tells whether a rule applies to the current request.
+type Condition interface {
+	Match(rw http.ResponseWriter, req *http.Request) bool
+}
+
+// builder returns the conditions configured in a rule, if any.
+type builder func(rule types.Rule) ([]Condition, error)
+
+// all matches when every one of its conditions matches.
+type all []Condition
+
+func (a all) Match(rw http.ResponseWriter, req *http.Request) bool {
+	for _, condition := range a {
+		if !condition.Match(rw, req) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// New compiles the conditions of a rule. A rule without any condition always matches.
+// The resolver finds the client IP for CIDR conditions; a nil resolver uses the peer address.
+func New(rule types.Rule, resolver *clientip.Resolver) (Condition, error) {
+	builders := []builder{
+		newPath,
+		newMethod,
+		newScheme,
+		newHeader,
+		newStatus,
+		newClientCIDR(resolver),
+		newExpression,
+	}
+
+	conditions := all{}
+
+	for _, build := range builders {
+		built, err := build(rule)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, built...)
+	}
+
+	return conditions, nil
+}
@@ -0,0 +1,30 @@
+package condition
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/tomMoulard/htransformation/pkg/expr"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+type expressionCondition struct {
+	expression *expr.Expression
+}
+
+func newExpression(rule types.Rule) ([]Condition, error) {
+	if rule.When == "" {
+		return nil, nil
+	}
+
+	expression, err := expr.Compile(rule.When, rule.SetOnResponse)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidCondition, err)
+	}
+
+	return []Condition{&expressionCondition{expression: expression}}, nil
+}
+
+func (e *expressionCondition) Match(rw http.ResponseWriter, req *http.Request) bool {
+	return e.expression.Eval(rw, req)
+}
@@ -0,0 +1,29 @@
+package condition_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/condition"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestExpressionCondition(t *testing.T) {
+	t.Parallel()
+
+	cond, err := condition.New(types.Rule{When: `method == "GET" && header("Accept") =~ "json"`}, nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.Header.Set("Accept", "application/json")
+	assert.Equal(t, true, cond.Match(httptest.NewRecorder(), req))
+
+	req.Header.Set("Accept", "text/html")
+	assert.Equal(t, false, cond.Match(httptest.NewRecorder(), req))
+
+	_, err = condition.New(types.Rule{When: `method ==`}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,132 @@
+package condition
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+)
+
+// headerCondition implements the If and Unless conditions, on a header or a query parameter.
+type headerCondition struct {
+	header   string
+	query    string
+	value    string
+	regexp   *regexp.Regexp
+	absent   bool
+	response bool
+	// negate is set for Unless conditions.
+	negate bool
+}
+
+func newHeader(rule types.Rule) ([]Condition, error) {
+	conditions := make([]Condition, 0, len(rule.If)+len(rule.Unless))
+
+	for _, cfg := range rule.If {
+		cond, err := newHeaderCondition(cfg, rule.SetOnResponse, false)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, cond)
+	}
+
+	for _, cfg := range rule.Unless {
+		cond, err := newHeaderCondition(cfg, rule.SetOnResponse, true)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, cond)
+	}
+
+	return conditions, nil
+}
+
+func newHeaderCondition(cfg types.Condition, onResponse, negate bool) (*headerCondition, error) {
+	if (cfg.Header == "") == (cfg.Query == "") {
+		return nil, fmt.Errorf("%w: exactly one of Header or Query is required", types.ErrInvalidCondition)
+	}
+
+	name := cfg.Header + cfg.Query
+
+	if cfg.Absent && (cfg.Value != "" || cfg.Matches != "") {
+		return nil, fmt.Errorf("%w: %s: Absent cannot be combined with Value or Matches", types.ErrInvalidCondition, name)
+	}
+
+	if cfg.Response && cfg.Query != "" {
+		return nil, fmt.Errorf("%w: %s: responses have no query parameters", types.ErrInvalidCondition, name)
+	}
+
+	if cfg.Response && header.IsOriginal(cfg.Header) {
+		return nil, fmt.Errorf("%w: %s: original headers are request headers", types.ErrInvalidCondition, name)
+	}
+
+	if cfg.Response && !onResponse {
+		return nil, fmt.Errorf("%w: %s: response headers are only available to response rules", types.ErrInvalidCondition, name)
+	}
+
+	cond := &headerCondition{
+		header:   cfg.Header,
+		query:    cfg.Query,
+		value:    cfg.Value,
+		absent:   cfg.Absent,
+		response: cfg.Response,
+		negate:   negate,
+	}
+
+	if cfg.Matches != "" {
+		reg, err := regexp.Compile(cfg.Matches)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", types.ErrInvalidRegexp, cfg.Matches)
+		}
+
+		cond.regexp = reg
+	}
+
+	return cond, nil
+}
+
+func (h *headerCondition) values(rw http.ResponseWriter, req *http.Request) []string {
+	if h.query != "" {
+		return req.URL.Query()[h.query]
+	}
+
+	if h.response {
+		return rw.Header().Values(h.header)
+	}
+
+	return header.Values(req, h.header)
+}
+
+func (h *headerCondition) Match(rw http.ResponseWriter, req *http.Request) bool {
+	values := h.values(rw, req)
+
+	var matched bool
+	if h.absent {
+		matched = len(values) == 0
+	} else {
+		matched = h.matchValues(values)
+	}
+
+	return matched != h.negate
+}
+
+// matchValues reports whether one of the header values satisfies the condition.
+func (h *headerCondition) matchValues(values []string) bool {
+	for _, value := range values {
+		if h.value != "" && value != h.value {
+			continue
+		}
+
+		if h.regexp != nil && !h.regexp.MatchString(value) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
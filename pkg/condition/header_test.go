@@ -0,0 +1,278 @@
+package condition_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/condition"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestHeaderCondition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		rule            types.Rule
+		requestHeaders  map[string]string
+		responseHeaders map[string]string
+		wantMatch       bool
+	}{
+		{
+			name:           "present",
+			rule:           types.Rule{If: []types.Condition{{Header: "X-Foo"}}},
+			requestHeaders: map[string]string{"X-Foo": "bar"},
+			wantMatch:      true,
+		},
+		{
+			name:      "not present",
+			rule:      types.Rule{If: []types.Condition{{Header: "X-Foo"}}},
+			wantMatch: false,
+		},
+		{
+			name:      "absent",
+			rule:      types.Rule{If: []types.Condition{{Header: "X-Foo", Absent: true}}},
+			wantMatch: true,
+		},
+		{
+			name:           "exact value",
+			rule:           types.Rule{If: []types.Condition{{Header: "X-Env", Value: "staging"}}},
+			requestHeaders: map[string]string{"X-Env": "staging"},
+			wantMatch:      true,
+		},
+		{
+			name:           "other value",
+			rule:           types.Rule{If: []types.Condition{{Header: "X-Env", Value: "staging"}}},
+			requestHeaders: map[string]string{"X-Env": "production"},
+			wantMatch:      false,
+		},
+		{
+			name:           "regexp",
+			rule:           types.Rule{If: []types.Condition{{Header: "Accept", Matches: "json$"}}},
+			requestHeaders: map[string]string{"Accept": "application/json"},
+			wantMatch:      true,
+		},
+		{
+			name:           "host",
+			rule:           types.Rule{If: []types.Condition{{Header: "Host", Value: "example.com"}}},
+			requestHeaders: map[string]string{},
+			wantMatch:      true,
+		},
+		{
+			name: "all if conditions must match",
+			rule: types.Rule{If: []types.Condition{
+				{Header: "X-Foo"},
+				{Header: "X-Bar"},
+			}},
+			requestHeaders: map[string]string{"X-Foo": "foo"},
+			wantMatch:      false,
+		},
+		{
+			name:           "unless matching",
+			rule:           types.Rule{Unless: []types.Condition{{Header: "X-Debug", Matches: "^(1|true)$"}}},
+			requestHeaders: map[string]string{"X-Debug": "true"},
+			wantMatch:      false,
+		},
+		{
+			name:           "unless not matching",
+			rule:           types.Rule{Unless: []types.Condition{{Header: "X-Debug", Matches: "^(1|true)$"}}},
+			requestHeaders: map[string]string{"X-Debug": "false"},
+			wantMatch:      true,
+		},
+		{
+			name: "response header",
+			rule: types.Rule{
+				SetOnResponse: true,
+				If:            []types.Condition{{Header: "Content-Type", Matches: "^text/", Response: true}},
+			},
+			responseHeaders: map[string]string{"Content-Type": "text/html"},
+			wantMatch:       true,
+		},
+		{
+			name: "request header on response rule",
+			rule: types.Rule{
+				SetOnResponse: true,
+				If:            []types.Condition{{Header: "Content-Type", Matches: "^text/"}},
+			},
+			requestHeaders:  map[string]string{"Content-Type": "application/json"},
+			responseHeaders: map[string]string{"Content-Type": "text/html"},
+			wantMatch:       false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cond, err := condition.New(test.rule, nil)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+			for hName, hVal := range test.requestHeaders {
+				req.Header.Set(hName, hVal)
+			}
+
+			recorder := httptest.NewRecorder()
+			for hName, hVal := range test.responseHeaders {
+				recorder.Header().Set(hName, hVal)
+			}
+
+			assert.Equal(t, test.wantMatch, cond.Match(recorder, req))
+		})
+	}
+}
+
+func TestOriginalHeaderCondition(t *testing.T) {
+	t.Parallel()
+
+	cond, err := condition.New(types.Rule{
+		If:     []types.Condition{{Header: "original:X-Env", Value: "staging"}},
+		Unless: []types.Condition{{Header: "original:X-Debug"}},
+	}, nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.Header.Set("X-Env", "staging")
+
+	state := &types.State{}
+	state.SaveOriginal(req)
+	req = types.WithState(req, state)
+
+	// Rules changing the headers do not change the original ones.
+	req.Header.Set("X-Env", "production")
+	req.Header.Set("X-Debug", "true")
+
+	assert.Equal(t, true, cond.Match(httptest.NewRecorder(), req))
+}
+
+func TestHeaderConditionValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "missing header",
+			rule:    types.Rule{If: []types.Condition{{Value: "foo"}}},
+			wantErr: true,
+		},
+		{
+			name:    "absent with value",
+			rule:    types.Rule{Unless: []types.Condition{{Header: "X-Foo", Value: "foo", Absent: true}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid regexp",
+			rule:    types.Rule{If: []types.Condition{{Header: "X-Foo", Matches: "("}}},
+			wantErr: true,
+		},
+		{
+			name:    "response header on request rule",
+			rule:    types.Rule{If: []types.Condition{{Header: "X-Foo", Response: true}}},
+			wantErr: true,
+		},
+		{
+			name: "original header on response",
+			rule: types.Rule{
+				SetOnResponse: true,
+				If:            []types.Condition{{Header: "original:X-Foo", Response: true}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid",
+			rule: types.Rule{
+				SetOnResponse: true,
+				If:            []types.Condition{{Header: "X-Foo", Response: true}},
+				Unless:        []types.Condition{{Header: "X-Bar", Matches: "^b"}},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := condition.New(test.rule, nil)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestQueryCondition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		rule      types.Rule
+		url       string
+		wantMatch bool
+	}{
+		{
+			name:      "present",
+			rule:      types.Rule{If: []types.Condition{{Query: "tenant"}}},
+			url:       "http://example.com/foo?tenant=acme",
+			wantMatch: true,
+		},
+		{
+			name:      "present without value",
+			rule:      types.Rule{If: []types.Condition{{Query: "debug"}}},
+			url:       "http://example.com/foo?debug",
+			wantMatch: true,
+		},
+		{
+			name:      "not present",
+			rule:      types.Rule{If: []types.Condition{{Query: "tenant"}}},
+			url:       "http://example.com/foo",
+			wantMatch: false,
+		},
+		{
+			name:      "exact value",
+			rule:      types.Rule{If: []types.Condition{{Query: "tenant", Value: "acme"}}},
+			url:       "http://example.com/foo?tenant=other&tenant=acme",
+			wantMatch: true,
+		},
+		{
+			name:      "unless regexp",
+			rule:      types.Rule{Unless: []types.Condition{{Query: "tenant", Matches: "^test-"}}},
+			url:       "http://example.com/foo?tenant=test-acme",
+			wantMatch: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cond, err := condition.New(test.rule, nil)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, test.url, nil)
+
+			assert.Equal(t, test.wantMatch, cond.Match(httptest.NewRecorder(), req))
+		})
+	}
+}
+
+func TestQueryConditionValidation(t *testing.T) {
+	t.Parallel()
+
+	_, err := condition.New(types.Rule{If: []types.Condition{{Header: "X-Foo", Query: "foo"}}}, nil)
+	assert.Error(t, err)
+
+	_, err = condition.New(types.Rule{
+		SetOnResponse: true,
+		If:            []types.Condition{{Query: "foo", Response: true}},
+	}, nil)
+	assert.Error(t, err)
+}
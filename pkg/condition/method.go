@@ -0,0 +1,31 @@
+package condition
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+type methodCondition struct {
+	methods map[string]struct{}
+}
+
+func newMethod(rule types.Rule) ([]Condition, error) {
+	if len(rule.Methods) == 0 {
+		return nil, nil
+	}
+
+	methods := make(map[string]struct{}, len(rule.Methods))
+	for _, method := range rule.Methods {
+		methods[strings.ToUpper(method)] = struct{}{}
+	}
+
+	return []Condition{&methodCondition{methods: methods}}, nil
+}
+
+func (m *methodCondition) Match(_ http.ResponseWriter, req *http.Request) bool {
+	_, ok := m.methods[req.Method]
+
+	return ok
+}
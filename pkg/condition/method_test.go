@@ -0,0 +1,60 @@
+package condition_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/condition"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestMethodCondition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		methods   []string
+		method    string
+		wantMatch bool
+	}{
+		{
+			name:      "no methods",
+			method:    http.MethodDelete,
+			wantMatch: true,
+		},
+		{
+			name:      "listed method",
+			methods:   []string{http.MethodPost, http.MethodPut},
+			method:    http.MethodPut,
+			wantMatch: true,
+		},
+		{
+			name:      "lower case method",
+			methods:   []string{"post"},
+			method:    http.MethodPost,
+			wantMatch: true,
+		},
+		{
+			name:      "unlisted method",
+			methods:   []string{http.MethodPost, http.MethodPut},
+			method:    http.MethodGet,
+			wantMatch: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cond, err := condition.New(types.Rule{Methods: test.methods}, nil)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(test.method, "http://example.com/foo", nil)
+
+			assert.Equal(t, test.wantMatch, cond.Match(httptest.NewRecorder(), req))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package condition
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+type pathCondition struct {
+	match func(requestPath string) bool
+}
+
+func newPath(rule types.Rule) ([]Condition, error) {
+	if rule.Path == "" {
+		return nil, nil
+	}
+
+	var match func(string) bool
+
+	switch rule.PathMatch {
+	case types.PathExact, "":
+		match = func(requestPath string) bool { return requestPath == rule.Path }
+	case types.PathPrefix:
+		match = func(requestPath string) bool { return strings.HasPrefix(requestPath, rule.Path) }
+	case types.PathGlob:
+		// path.Match only reports a malformed pattern when it is evaluated.
+		if _, err := path.Match(rule.Path, ""); err != nil {
+			return nil, fmt.Errorf("%w: path glob %q", types.ErrInvalidCondition, rule.Path)
+		}
+
+		match = func(requestPath string) bool {
+			matched, _ := path.Match(rule.Path, requestPath)
+
+			return matched
+		}
+	case types.PathRegexp:
+		reg, err := regexp.Compile(rule.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", types.ErrInvalidRegexp, rule.Path)
+		}
+
+		match = reg.MatchString
+	default:
+		return nil, fmt.Errorf("%w: unknown path match %q", types.ErrInvalidCondition, rule.PathMatch)
+	}
+
+	return []Condition{&pathCondition{match: match}}, nil
+}
+
+func (p *pathCondition) Match(_ http.ResponseWriter, req *http.Request) bool {
+	return p.match(req.URL.Path)
+}
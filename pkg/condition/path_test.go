@@ -0,0 +1,135 @@
+package condition_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/condition"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestPathCondition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		rule      types.Rule
+		path      string
+		wantMatch bool
+	}{
+		{
+			name:      "no path",
+			rule:      types.Rule{},
+			path:      "/foo",
+			wantMatch: true,
+		},
+		{
+			name:      "exact match by default",
+			rule:      types.Rule{Path: "/foo"},
+			path:      "/foo",
+			wantMatch: true,
+		},
+		{
+			name:      "exact mismatch",
+			rule:      types.Rule{Path: "/foo", PathMatch: types.PathExact},
+			path:      "/foo/bar",
+			wantMatch: false,
+		},
+		{
+			name:      "prefix match",
+			rule:      types.Rule{Path: "/api/", PathMatch: types.PathPrefix},
+			path:      "/api/users/1",
+			wantMatch: true,
+		},
+		{
+			name:      "prefix mismatch",
+			rule:      types.Rule{Path: "/api/", PathMatch: types.PathPrefix},
+			path:      "/static/app.js",
+			wantMatch: false,
+		},
+		{
+			name:      "glob match",
+			rule:      types.Rule{Path: "/static/*.js", PathMatch: types.PathGlob},
+			path:      "/static/app.js",
+			wantMatch: true,
+		},
+		{
+			name:      "glob does not cross slashes",
+			rule:      types.Rule{Path: "/static/*", PathMatch: types.PathGlob},
+			path:      "/static/js/app.js",
+			wantMatch: false,
+		},
+		{
+			name:      "regexp match",
+			rule:      types.Rule{Path: `^/v[0-9]+/`, PathMatch: types.PathRegexp},
+			path:      "/v2/users",
+			wantMatch: true,
+		},
+		{
+			name:      "regexp mismatch",
+			rule:      types.Rule{Path: `^/v[0-9]+/`, PathMatch: types.PathRegexp},
+			path:      "/users",
+			wantMatch: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cond, err := condition.New(test.rule, nil)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com"+test.path, nil)
+
+			assert.Equal(t, test.wantMatch, cond.Match(httptest.NewRecorder(), req))
+		})
+	}
+}
+
+func TestPathConditionValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "valid glob",
+			rule:    types.Rule{Path: "/api/*", PathMatch: types.PathGlob},
+			wantErr: false,
+		},
+		{
+			name:    "invalid glob",
+			rule:    types.Rule{Path: "/api/[", PathMatch: types.PathGlob},
+			wantErr: true,
+		},
+		{
+			name:    "invalid regexp",
+			rule:    types.Rule{Path: "/api/(", PathMatch: types.PathRegexp},
+			wantErr: true,
+		},
+		{
+			name:    "unknown path match",
+			rule:    types.Rule{Path: "/api", PathMatch: "Fuzzy"},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := condition.New(test.rule, nil)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package condition
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+type schemeCondition struct {
+	https bool
+}
+
+func newScheme(rule types.Rule) ([]Condition, error) {
+	switch strings.ToLower(rule.Scheme) {
+	case "":
+		return nil, nil
+	case "http":
+		return []Condition{&schemeCondition{https: false}}, nil
+	case "https":
+		return []Condition{&schemeCondition{https: true}}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown scheme %q", types.ErrInvalidCondition, rule.Scheme)
+	}
+}
+
+// Match relies on req.TLS, which is set when the connection to Traefik is encrypted.
+func (s *schemeCondition) Match(_ http.ResponseWriter, req *http.Request) bool {
+	return (req.TLS != nil) == s.https
+}
@@ -0,0 +1,77 @@
+package condition_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/condition"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestSchemeCondition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		scheme    string
+		tls       bool
+		wantMatch bool
+	}{
+		{
+			name:      "no scheme",
+			tls:       true,
+			wantMatch: true,
+		},
+		{
+			name:      "https over tls",
+			scheme:    "https",
+			tls:       true,
+			wantMatch: true,
+		},
+		{
+			name:      "https over plain http",
+			scheme:    "HTTPS",
+			tls:       false,
+			wantMatch: false,
+		},
+		{
+			name:      "http over plain http",
+			scheme:    "http",
+			tls:       false,
+			wantMatch: true,
+		},
+		{
+			name:      "http over tls",
+			scheme:    "http",
+			tls:       true,
+			wantMatch: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cond, err := condition.New(types.Rule{Scheme: test.scheme}, nil)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+			if test.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+
+			assert.Equal(t, test.wantMatch, cond.Match(httptest.NewRecorder(), req))
+		})
+	}
+}
+
+func TestSchemeConditionValidation(t *testing.T) {
+	t.Parallel()
+
+	_, err := condition.New(types.Rule{Scheme: "ftp"}, nil)
+	assert.Error(t, err)
+}
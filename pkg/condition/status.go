@@ -0,0 +1,87 @@
+package condition
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+// statusRange is an inclusive range of status codes.
+type statusRange struct {
+	low  int
+	high int
+}
+
+type statusCondition struct {
+	ranges []statusRange
+}
+
+func newStatus(rule types.Rule) ([]Condition, error) {
+	if len(rule.Status) == 0 {
+		return nil, nil
+	}
+
+	if !rule.SetOnResponse {
+		return nil, fmt.Errorf("%w: status codes are only available to response rules", types.ErrInvalidCondition)
+	}
+
+	ranges := make([]statusRange, 0, len(rule.Status))
+
+	for _, status := range rule.Status {
+		statusRange, err := parseStatusRange(status)
+		if err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, statusRange)
+	}
+
+	return []Condition{&statusCondition{ranges: ranges}}, nil
+}
+
+// parseStatusRange parses an exact code (503), a range (500-599) or a class (5xx).
+func parseStatusRange(status string) (statusRange, error) {
+	status = strings.TrimSpace(status)
+	invalid := fmt.Errorf("%w: invalid status %q", types.ErrInvalidCondition, status)
+
+	if len(status) == 3 && strings.EqualFold(status[1:], "xx") {
+		class, err := strconv.Atoi(status[:1])
+		if err != nil || class < 1 || class > 5 {
+			return statusRange{}, invalid
+		}
+
+		return statusRange{low: class * 100, high: class*100 + 99}, nil
+	}
+
+	lowStr, highStr, isRange := strings.Cut(status, "-")
+	if !isRange {
+		highStr = lowStr
+	}
+
+	low, err := strconv.Atoi(strings.TrimSpace(lowStr))
+	if err != nil {
+		return statusRange{}, invalid
+	}
+
+	high, err := strconv.Atoi(strings.TrimSpace(highStr))
+	if err != nil || low > high {
+		return statusRange{}, invalid
+	}
+
+	return statusRange{low: low, high: high}, nil
+}
+
+func (s *statusCondition) Match(_ http.ResponseWriter, req *http.Request) bool {
+	statusCode := types.GetState(req).StatusCode
+
+	for _, statusRange := range s.ranges {
+		if statusCode >= statusRange.low && statusCode <= statusRange.high {
+			return true
+		}
+	}
+
+	return false
+}
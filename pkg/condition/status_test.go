@@ -0,0 +1,117 @@
+package condition_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/condition"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestStatusCondition(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		status     []string
+		statusCode int
+		wantMatch  bool
+	}{
+		{
+			name:       "exact code",
+			status:     []string{"503"},
+			statusCode: http.StatusServiceUnavailable,
+			wantMatch:  true,
+		},
+		{
+			name:       "other code",
+			status:     []string{"503"},
+			statusCode: http.StatusBadGateway,
+			wantMatch:  false,
+		},
+		{
+			name:       "range",
+			status:     []string{"400-499"},
+			statusCode: http.StatusNotFound,
+			wantMatch:  true,
+		},
+		{
+			name:       "class",
+			status:     []string{"5xx"},
+			statusCode: http.StatusInternalServerError,
+			wantMatch:  true,
+		},
+		{
+			name:       "one of several",
+			status:     []string{"4xx", "5xx"},
+			statusCode: http.StatusOK,
+			wantMatch:  false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cond, err := condition.New(types.Rule{Status: test.status, SetOnResponse: true}, nil)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+			req = types.WithState(req, &types.State{StatusCode: test.statusCode})
+
+			assert.Equal(t, test.wantMatch, cond.Match(httptest.NewRecorder(), req))
+		})
+	}
+}
+
+func TestStatusConditionValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "request rule",
+			rule:    types.Rule{Status: []string{"200"}},
+			wantErr: true,
+		},
+		{
+			name:    "not a number",
+			rule:    types.Rule{Status: []string{"ok"}, SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "invalid class",
+			rule:    types.Rule{Status: []string{"9xx"}, SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "reversed range",
+			rule:    types.Rule{Status: []string{"599-500"}, SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "valid",
+			rule:    types.Rule{Status: []string{"200", "300-399", "5XX"}, SetOnResponse: true},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := condition.New(test.rule, nil)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
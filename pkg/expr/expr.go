@@ -0,0 +1,51 @@
+// Package expr implements the boolean expressions used as rule conditions.
+//
+//	(method == "GET" && header("Accept") =~ "json") || path startsWith "/v2"
+//
+// Expressions are parsed and type-checked once, then evaluated on each request.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var ErrInvalidExpression = errors.New("invalid expression")
+
+// Expression is a compiled boolean expression.
+type Expression struct {
+	eval boolFunc
+}
+
+// Compile parses and type-checks source. The status accessor and the response
+// headers (header("resp:Name")) are only available when the expression is
+// evaluated on responses.
+func Compile(source string, onResponse bool) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, onResponse: onResponse}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("%w: unexpected %q at %d", ErrInvalidExpression, tok.text, tok.pos)
+	}
+
+	if root.kind != kindBool {
+		return nil, fmt.Errorf("%w: expression is a %s, not a bool", ErrInvalidExpression, root.kind)
+	}
+
+	return &Expression{eval: root.boolean}, nil
+}
+
+// Eval evaluates the expression against a request and, for response rules, its response.
+func (e *Expression) Eval(rw http.ResponseWriter, req *http.Request) bool {
+	return e.eval(rw, req)
+}
@@ -0,0 +1,271 @@
+package expr_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/expr"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		expression string
+		method     string
+		url        string
+		headers    map[string]string
+		// respHeaders are the headers of the response.
+		respHeaders map[string]string
+		statusCode  int
+		want        bool
+	}{
+		{
+			name:       "method equality",
+			expression: `method == "GET"`,
+			want:       true,
+		},
+		{
+			name:       "method inequality",
+			expression: `method != "GET"`,
+			want:       false,
+		},
+		{
+			name:       "header regexp",
+			expression: `header("Accept") =~ "json$"`,
+			headers:    map[string]string{"Accept": "application/json"},
+			want:       true,
+		},
+		{
+			name:       "header negated regexp",
+			expression: "header(\"Accept\") !~ `^text/`",
+			headers:    map[string]string{"Accept": "application/json"},
+			want:       true,
+		},
+		{
+			name:       "missing header is empty",
+			expression: `header("X-Missing") == ""`,
+			want:       true,
+		},
+		{
+			name:       "host header",
+			expression: `header("Host") == host && host endsWith ".com"`,
+			want:       true,
+		},
+		{
+			name:       "query and cookie",
+			expression: `query("tenant") == "acme" and cookie("session") contains "abc"`,
+			url:        "http://example.com/foo?tenant=acme",
+			headers:    map[string]string{"Cookie": "session=xabcx"},
+			want:       true,
+		},
+		{
+			name:       "precedence",
+			expression: `(method == "GET" && header("Accept") =~ "json") || path startsWith "/v2"`,
+			method:     http.MethodPost,
+			url:        "http://example.com/v2/users",
+			want:       true,
+		},
+		{
+			name:       "and binds tighter than or",
+			expression: `method == "POST" || method == "GET" && path == "/nope"`,
+			want:       false,
+		},
+		{
+			name:       "not",
+			expression: `not (path startsWith "/admin") && !false`,
+			want:       true,
+		},
+		{
+			name:       "status class",
+			expression: `status >= 500 && status < 600`,
+			statusCode: http.StatusBadGateway,
+			want:       true,
+		},
+		{
+			name:       "status equality",
+			expression: `status == 404`,
+			statusCode: http.StatusOK,
+			want:       false,
+		},
+		{
+			name:        "response header",
+			expression:  `header("resp:Content-Type") startsWith "text/" && header("req:Content-Type") == ""`,
+			respHeaders: map[string]string{"Content-Type": "text/html"},
+			want:        true,
+		},
+		{
+			name:       "escaped string",
+			expression: `header("X-Quote") == "a\"b"`,
+			headers:    map[string]string{"X-Quote": `a"b`},
+			want:       true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			expression, err := expr.Compile(test.expression, true)
+			require.NoError(t, err)
+
+			method, url := test.method, test.url
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			if url == "" {
+				url = "http://example.com/foo"
+			}
+
+			req := httptest.NewRequest(method, url, nil)
+			for hName, hVal := range test.headers {
+				req.Header.Set(hName, hVal)
+			}
+
+			req = types.WithState(req, &types.State{StatusCode: test.statusCode})
+
+			recorder := httptest.NewRecorder()
+			for hName, hVal := range test.respHeaders {
+				recorder.Header().Set(hName, hVal)
+			}
+
+			assert.Equal(t, test.want, expression.Eval(recorder, req))
+		})
+	}
+}
+
+func TestEvalOriginal(t *testing.T) {
+	t.Parallel()
+
+	expression, err := expr.Compile(`header("original:Host") != host && header("original:X-Env") == "staging"`, false)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.Header.Set("X-Env", "staging")
+
+	state := &types.State{}
+	state.SaveOriginal(req)
+	req = types.WithState(req, state)
+
+	assert.Equal(t, false, expression.Eval(httptest.NewRecorder(), req))
+
+	req.Host = "backend.internal"
+	req.Header.Del("X-Env")
+
+	assert.Equal(t, true, expression.Eval(httptest.NewRecorder(), req))
+}
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		expression string
+		onResponse bool
+		wantErr    bool
+	}{
+		{
+			name:       "valid",
+			expression: `method == "GET" || path startsWith "/v2"`,
+			wantErr:    false,
+		},
+		{
+			name:       "not a bool",
+			expression: `path`,
+			wantErr:    true,
+		},
+		{
+			name:       "mismatched kinds",
+			expression: `status == "200"`,
+			onResponse: true,
+			wantErr:    true,
+		},
+		{
+			name:       "ordering strings",
+			expression: `path > "/a"`,
+			wantErr:    true,
+		},
+		{
+			name:       "and on strings",
+			expression: `path && method`,
+			wantErr:    true,
+		},
+		{
+			name:       "status on request",
+			expression: `status == 200`,
+			onResponse: false,
+			wantErr:    true,
+		},
+		{
+			name:       "response header on request",
+			expression: `header("RESP:ETag") != ""`,
+			onResponse: false,
+			wantErr:    true,
+		},
+		{
+			name:       "response header on response",
+			expression: `header("resp:ETag") != ""`,
+			onResponse: true,
+			wantErr:    false,
+		},
+		{
+			name:       "unknown identifier",
+			expression: `scheme == "https"`,
+			wantErr:    true,
+		},
+		{
+			name:       "invalid regexp",
+			expression: `path =~ "("`,
+			wantErr:    true,
+		},
+		{
+			name:       "regexp must be a literal",
+			expression: `path =~ header("X-Pattern")`,
+			wantErr:    true,
+		},
+		{
+			name:       "unterminated string",
+			expression: `path == "/foo`,
+			wantErr:    true,
+		},
+		{
+			name:       "unbalanced parenthesis",
+			expression: `(path == "/foo"`,
+			wantErr:    true,
+		},
+		{
+			name:       "trailing tokens",
+			expression: `path == "/foo" "/bar"`,
+			wantErr:    true,
+		},
+		{
+			name:       "accessor without argument",
+			expression: `header == "foo"`,
+			wantErr:    true,
+		},
+		{
+			name:       "unexpected character",
+			expression: `path == '/foo'`,
+			wantErr:    true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := expr.Compile(test.expression, test.onResponse)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators are sorted so that the longest operators are tried first.
+var operators = []string{"==", "!=", "=~", "!~", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")"}
+
+// lex splits source into tokens, the last one being tokenEOF.
+func lex(source string) ([]token, error) {
+	var tokens []token
+
+	for pos := 0; pos < len(source); {
+		char := rune(source[pos])
+
+		switch {
+		case unicode.IsSpace(char):
+			pos++
+		case char == '"' || char == '`':
+			end := closingQuote(source, pos)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated string at %d", ErrInvalidExpression, pos)
+			}
+
+			text, err := strconv.Unquote(source[pos : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid string at %d", ErrInvalidExpression, pos)
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: pos})
+			pos = end + 1
+		case unicode.IsDigit(char):
+			end := scan(source, pos, unicode.IsDigit)
+			tokens = append(tokens, token{kind: tokenNumber, text: source[pos:end], pos: pos})
+			pos = end
+		case unicode.IsLetter(char) || char == '_':
+			end := scan(source, pos, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' })
+			tokens = append(tokens, token{kind: tokenIdent, text: source[pos:end], pos: pos})
+			pos = end
+		default:
+			operator := matchOperator(source[pos:])
+			if operator == "" {
+				return nil, fmt.Errorf("%w: unexpected %q at %d", ErrInvalidExpression, char, pos)
+			}
+
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: pos})
+			pos += len(operator)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(source)}), nil
+}
+
+// closingQuote returns the index of the quote closing the string starting at start, or -1.
+func closingQuote(source string, start int) int {
+	quote := source[start]
+
+	for i := start + 1; i < len(source); i++ {
+		switch {
+		case source[i] == '\\' && quote == '"':
+			i++
+		case source[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func scan(source string, start int, accept func(rune) bool) int {
+	end := start
+	for end < len(source) && accept(rune(source[end])) {
+		end++
+	}
+
+	return end
+}
+
+func matchOperator(source string) string {
+	for _, operator := range operators {
+		if strings.HasPrefix(source, operator) {
+			return operator
+		}
+	}
+
+	return ""
+}
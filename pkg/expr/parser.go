@@ -0,0 +1,415 @@
+package expr
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/value"
+)
+
+type (
+	stringFunc func(rw http.ResponseWriter, req *http.Request) string
+	intFunc    func(rw http.ResponseWriter, req *http.Request) int
+	boolFunc   func(rw http.ResponseWriter, req *http.Request) bool
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindBool
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindString:
+		return "string"
+	case kindInt:
+		return "int"
+	default:
+		return "bool"
+	}
+}
+
+// operand is a typed sub-expression: only the function matching its kind is set.
+type operand struct {
+	kind    kind
+	str     stringFunc
+	integer intFunc
+	boolean boolFunc
+	// literal is set for string literals, so that regexps are compiled once.
+	literal *string
+}
+
+type parser struct {
+	tokens     []token
+	pos        int
+	onResponse bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *parser) expect(text string) error {
+	if tok := p.next(); tok.text != text || tok.kind == tokenString {
+		return fmt.Errorf("%w: expected %q at %d", ErrInvalidExpression, text, tok.pos)
+	}
+
+	return nil
+}
+
+// accept consumes the next token if it is one of the given operators or keywords.
+func (p *parser) accept(texts ...string) (token, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOperator && tok.kind != tokenIdent {
+		return tok, false
+	}
+
+	for _, text := range texts {
+		if tok.text == text {
+			return p.next(), true
+		}
+	}
+
+	return tok, false
+}
+
+func (p *parser) parseOr() (operand, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return operand{}, err
+	}
+
+	for {
+		tok, ok := p.accept("||", "or")
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return operand{}, err
+		}
+
+		if err := checkKinds(tok, kindBool, left, right); err != nil {
+			return operand{}, err
+		}
+
+		l, r := left.boolean, right.boolean
+		left = boolOperand(func(rw http.ResponseWriter, req *http.Request) bool { return l(rw, req) || r(rw, req) })
+	}
+}
+
+func (p *parser) parseAnd() (operand, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return operand{}, err
+	}
+
+	for {
+		tok, ok := p.accept("&&", "and")
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.parseNot()
+		if err != nil {
+			return operand{}, err
+		}
+
+		if err := checkKinds(tok, kindBool, left, right); err != nil {
+			return operand{}, err
+		}
+
+		l, r := left.boolean, right.boolean
+		left = boolOperand(func(rw http.ResponseWriter, req *http.Request) bool { return l(rw, req) && r(rw, req) })
+	}
+}
+
+func (p *parser) parseNot() (operand, error) {
+	tok, ok := p.accept("!", "not")
+	if !ok {
+		return p.parseComparison()
+	}
+
+	inner, err := p.parseNot()
+	if err != nil {
+		return operand{}, err
+	}
+
+	if err := checkKinds(tok, kindBool, inner); err != nil {
+		return operand{}, err
+	}
+
+	eval := inner.boolean
+
+	return boolOperand(func(rw http.ResponseWriter, req *http.Request) bool { return !eval(rw, req) }), nil
+}
+
+func (p *parser) parseComparison() (operand, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return operand{}, err
+	}
+
+	tok, ok := p.accept("==", "!=", "=~", "!~", "<", "<=", ">", ">=", "startsWith", "endsWith", "contains")
+	if !ok {
+		return left, nil
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return operand{}, err
+	}
+
+	switch tok.text {
+	case "==", "!=":
+		return compareEquality(tok, left, right)
+	case "=~", "!~":
+		return compareRegexp(tok, left, right)
+	case "<", "<=", ">", ">=":
+		return compareOrder(tok, left, right)
+	default:
+		return compareStrings(tok, left, right)
+	}
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenString:
+		text := tok.text
+
+		return operand{
+			kind:    kindString,
+			str:     func(http.ResponseWriter, *http.Request) string { return text },
+			literal: &text,
+		}, nil
+	case tokenNumber:
+		number, err := strconv.Atoi(tok.text)
+		if err != nil {
+			return operand{}, fmt.Errorf("%w: invalid number %q at %d", ErrInvalidExpression, tok.text, tok.pos)
+		}
+
+		return operand{
+			kind:    kindInt,
+			integer: func(http.ResponseWriter, *http.Request) int { return number },
+		}, nil
+	case tokenIdent:
+		return p.parseIdent(tok)
+	case tokenOperator:
+		if tok.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return operand{}, err
+			}
+
+			return inner, p.expect(")")
+		}
+	case tokenEOF:
+	}
+
+	return operand{}, fmt.Errorf("%w: unexpected %q at %d", ErrInvalidExpression, tok.text, tok.pos)
+}
+
+func (p *parser) parseIdent(tok token) (operand, error) {
+	switch tok.text {
+	case "true", "false":
+		truth := tok.text == "true"
+
+		return boolOperand(func(http.ResponseWriter, *http.Request) bool { return truth }), nil
+	case "method":
+		return stringOperand(func(_ http.ResponseWriter, req *http.Request) string { return req.Method }), nil
+	case "path":
+		return stringOperand(func(_ http.ResponseWriter, req *http.Request) string { return req.URL.Path }), nil
+	case "host":
+		return stringOperand(func(_ http.ResponseWriter, req *http.Request) string { return req.Host }), nil
+	case "status":
+		if !p.onResponse {
+			return operand{}, fmt.Errorf("%w: status is only available to response rules at %d", ErrInvalidExpression, tok.pos)
+		}
+
+		return operand{
+			kind: kindInt,
+			integer: func(_ http.ResponseWriter, req *http.Request) int {
+				return types.GetState(req).StatusCode
+			},
+		}, nil
+	case "header", "query", "cookie":
+		name, err := p.parseArgument()
+		if err != nil {
+			return operand{}, err
+		}
+
+		if tok.text == "header" && !p.onResponse && value.IsResponseHeader(name) {
+			return operand{}, fmt.Errorf("%w: response headers are only available to response rules at %d", ErrInvalidExpression, tok.pos)
+		}
+
+		return stringOperand(accessor(tok.text, name)), nil
+	}
+
+	return operand{}, fmt.Errorf("%w: unknown identifier %q at %d", ErrInvalidExpression, tok.text, tok.pos)
+}
+
+// parseArgument parses the string argument of an accessor, such as ("X-Foo").
+func (p *parser) parseArgument() (string, error) {
+	if err := p.expect("("); err != nil {
+		return "", err
+	}
+
+	tok := p.next()
+	if tok.kind != tokenString {
+		return "", fmt.Errorf("%w: expected a string at %d", ErrInvalidExpression, tok.pos)
+	}
+
+	return tok.text, p.expect(")")
+}
+
+func accessor(name, argument string) stringFunc {
+	switch name {
+	case "query":
+		return func(_ http.ResponseWriter, req *http.Request) string { return req.URL.Query().Get(argument) }
+	case "cookie":
+		return func(_ http.ResponseWriter, req *http.Request) string {
+			cookie, err := req.Cookie(argument)
+			if err != nil {
+				return ""
+			}
+
+			return cookie.Value
+		}
+	default:
+		return func(rw http.ResponseWriter, req *http.Request) string {
+			return value.Header(rw, req, argument)
+		}
+	}
+}
+
+func compareEquality(tok token, left, right operand) (operand, error) {
+	if left.kind != right.kind {
+		return operand{}, fmt.Errorf("%w: cannot compare %s and %s with %q at %d", ErrInvalidExpression, left.kind, right.kind, tok.text, tok.pos)
+	}
+
+	var equal boolFunc
+
+	switch left.kind {
+	case kindString:
+		l, r := left.str, right.str
+		equal = func(rw http.ResponseWriter, req *http.Request) bool { return l(rw, req) == r(rw, req) }
+	case kindInt:
+		l, r := left.integer, right.integer
+		equal = func(rw http.ResponseWriter, req *http.Request) bool { return l(rw, req) == r(rw, req) }
+	case kindBool:
+		l, r := left.boolean, right.boolean
+		equal = func(rw http.ResponseWriter, req *http.Request) bool { return l(rw, req) == r(rw, req) }
+	}
+
+	if tok.text == "!=" {
+		return boolOperand(func(rw http.ResponseWriter, req *http.Request) bool { return !equal(rw, req) }), nil
+	}
+
+	return boolOperand(equal), nil
+}
+
+func compareRegexp(tok token, left, right operand) (operand, error) {
+	if err := checkKinds(tok, kindString, left, right); err != nil {
+		return operand{}, err
+	}
+
+	if right.literal == nil {
+		return operand{}, fmt.Errorf("%w: %q expects a string literal at %d", ErrInvalidExpression, tok.text, tok.pos)
+	}
+
+	reg, err := regexp.Compile(*right.literal)
+	if err != nil {
+		return operand{}, fmt.Errorf("%w: %q at %d", types.ErrInvalidRegexp, *right.literal, tok.pos)
+	}
+
+	l, negate := left.str, tok.text == "!~"
+
+	return boolOperand(func(rw http.ResponseWriter, req *http.Request) bool {
+		return reg.MatchString(l(rw, req)) != negate
+	}), nil
+}
+
+func compareOrder(tok token, left, right operand) (operand, error) {
+	if err := checkKinds(tok, kindInt, left, right); err != nil {
+		return operand{}, err
+	}
+
+	l, r := left.integer, right.integer
+
+	var compare func(a, b int) bool
+
+	switch tok.text {
+	case "<":
+		compare = func(a, b int) bool { return a < b }
+	case "<=":
+		compare = func(a, b int) bool { return a <= b }
+	case ">":
+		compare = func(a, b int) bool { return a > b }
+	default:
+		compare = func(a, b int) bool { return a >= b }
+	}
+
+	return boolOperand(func(rw http.ResponseWriter, req *http.Request) bool {
+		return compare(l(rw, req), r(rw, req))
+	}), nil
+}
+
+func compareStrings(tok token, left, right operand) (operand, error) {
+	if err := checkKinds(tok, kindString, left, right); err != nil {
+		return operand{}, err
+	}
+
+	l, r := left.str, right.str
+
+	var compare func(s, substr string) bool
+
+	switch tok.text {
+	case "startsWith":
+		compare = strings.HasPrefix
+	case "endsWith":
+		compare = strings.HasSuffix
+	default:
+		compare = strings.Contains
+	}
+
+	return boolOperand(func(rw http.ResponseWriter, req *http.Request) bool {
+		return compare(l(rw, req), r(rw, req))
+	}), nil
+}
+
+func checkKinds(tok token, expected kind, operands ...operand) error {
+	for _, o := range operands {
+		if o.kind != expected {
+			return fmt.Errorf("%w: %q expects %s operands, got %s at %d", ErrInvalidExpression, tok.text, expected, o.kind, tok.pos)
+		}
+	}
+
+	return nil
+}
+
+func stringOperand(eval stringFunc) operand {
+	return operand{kind: kindString, str: eval}
+}
+
+func boolOperand(eval boolFunc) operand {
+	return operand{kind: kindBool, boolean: eval}
+}
@@ -0,0 +1,176 @@
+// Package filter transforms the values written by rules. A chain of filters is
+// compiled from the rule Filters, each being a name optionally followed by a
+// colon and an argument, such as "lower" or "substring:0:8".
+package filter
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+// filters maps the filters without argument to their function.
+var filters = map[string]func(value string) string{
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"trim":   strings.TrimSpace,
+	"base64": func(value string) string { return base64.StdEncoding.EncodeToString([]byte(value)) },
+	"base64decode": func(value string) string {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return ""
+		}
+
+		return string(decoded)
+	},
+	"urlescape": url.QueryEscape,
+	"urlunescape": func(value string) string {
+		unescaped, err := url.QueryUnescape(value)
+		if err != nil {
+			return ""
+		}
+
+		return unescaped
+	},
+	"sha256": func(value string) string {
+		sum := sha256.Sum256([]byte(value))
+
+		return hex.EncodeToString(sum[:])
+	},
+}
+
+// builders maps the filters with an argument to the function compiling them.
+var builders = map[string]func(arg string) (func(value string) string, error){
+	"substring":  newSubstring,
+	"regex":      newRegex,
+	"trimprefix": func(prefix string) (func(string) string, error) { return trimPrefix(prefix), nil },
+	"trimsuffix": func(suffix string) (func(string) string, error) { return trimSuffix(suffix), nil },
+}
+
+// Chain applies filters in order.
+type Chain struct {
+	filters []func(value string) string
+	// err is the first compilation error, reported by Validate.
+	err error
+}
+
+// New compiles specs. Compilation errors are reported by Validate, so that
+// handlers keep rejecting invalid rules in their Validate method.
+func New(specs []string) *Chain {
+	chain := &Chain{}
+
+	for _, spec := range specs {
+		compiled, err := compile(spec)
+		if err != nil {
+			if chain.err == nil {
+				chain.err = err
+			}
+
+			continue
+		}
+
+		chain.filters = append(chain.filters, compiled)
+	}
+
+	return chain
+}
+
+func compile(spec string) (func(value string) string, error) {
+	name, arg, hasArg := strings.Cut(spec, ":")
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	if compiled, ok := filters[name]; ok && !hasArg {
+		return compiled, nil
+	}
+
+	if build, ok := builders[name]; ok && hasArg {
+		return build(arg)
+	}
+
+	return nil, fmt.Errorf("%w: %q", types.ErrInvalidFilter, spec)
+}
+
+// Validate returns the first error met while compiling the filters.
+func (c *Chain) Validate() error {
+	return c.err
+}
+
+// Apply returns value transformed by each filter in order.
+func (c *Chain) Apply(value string) string {
+	for _, filter := range c.filters {
+		value = filter(value)
+	}
+
+	return value
+}
+
+// newSubstring compiles "START" or "START:END" into a filter keeping the
+// characters from START (included) to END (excluded, the end of the value by default).
+func newSubstring(arg string) (func(string) string, error) {
+	startArg, endArg, hasEnd := strings.Cut(arg, ":")
+
+	start, err := strconv.Atoi(startArg)
+	if err != nil || start < 0 {
+		return nil, fmt.Errorf("%w: substring start %q", types.ErrInvalidFilter, startArg)
+	}
+
+	end := -1
+	if hasEnd {
+		end, err = strconv.Atoi(endArg)
+		if err != nil || end < start {
+			return nil, fmt.Errorf("%w: substring end %q", types.ErrInvalidFilter, endArg)
+		}
+	}
+
+	return func(value string) string {
+		runes := []rune(value)
+
+		to := len(runes)
+		if end >= 0 && end < to {
+			to = end
+		}
+
+		if start >= to {
+			return ""
+		}
+
+		return string(runes[start:to])
+	}, nil
+}
+
+// newRegex compiles a filter keeping the first match of pattern, or its first
+// capture group when it has one. Values that do not match become empty.
+func newRegex(pattern string) (func(string) string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", types.ErrInvalidRegexp, pattern)
+	}
+
+	return func(value string) string {
+		match := re.FindStringSubmatch(value)
+
+		switch {
+		case match == nil:
+			return ""
+		case len(match) > 1:
+			return match[1]
+		default:
+			return match[0]
+		}
+	}, nil
+}
+
+func trimPrefix(prefix string) func(string) string {
+	return func(value string) string { return strings.TrimPrefix(value, prefix) }
+}
+
+func trimSuffix(suffix string) func(string) string {
+	return func(value string) string { return strings.TrimSuffix(value, suffix) }
+}
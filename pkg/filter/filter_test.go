@@ -0,0 +1,92 @@
+package filter_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		filters  []string
+		value    string
+		expected string
+	}{
+		{name: "no filter", value: " Foo ", expected: " Foo "},
+		{name: "lower", filters: []string{"lower"}, value: "FoO", expected: "foo"},
+		{name: "upper", filters: []string{"upper"}, value: "FoO", expected: "FOO"},
+		{name: "trim", filters: []string{"trim"}, value: " \tfoo\n", expected: "foo"},
+		{name: "base64", filters: []string{"base64"}, value: "user:pass", expected: "dXNlcjpwYXNz"},
+		{name: "base64decode", filters: []string{"base64decode"}, value: "dXNlcjpwYXNz", expected: "user:pass"},
+		{name: "invalid base64", filters: []string{"base64decode"}, value: "not base64!", expected: ""},
+		{name: "urlescape", filters: []string{"urlescape"}, value: "a b&c=d", expected: "a+b%26c%3Dd"},
+		{name: "urlunescape", filters: []string{"urlunescape"}, value: "a+b%26c%3Dd", expected: "a b&c=d"},
+		{name: "invalid url escape", filters: []string{"urlunescape"}, value: "%zz", expected: ""},
+		{
+			name:     "sha256",
+			filters:  []string{"sha256"},
+			value:    "abc",
+			expected: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+		{name: "substring", filters: []string{"substring:2:5"}, value: "abcdefg", expected: "cde"},
+		{name: "substring to the end", filters: []string{"substring:4"}, value: "abcdefg", expected: "efg"},
+		{name: "substring out of range", filters: []string{"substring:4:10"}, value: "abc", expected: ""},
+		{name: "substring of runes", filters: []string{"substring:0:2"}, value: "héllo", expected: "hé"},
+		{name: "regex match", filters: []string{`regex:\d+`}, value: "order-1234-x", expected: "1234"},
+		{name: "regex capture group", filters: []string{`regex:tenant=([a-z]+)`}, value: "id=1;tenant=acme", expected: "acme"},
+		{name: "regex without match", filters: []string{`regex:\d+`}, value: "none", expected: ""},
+		{name: "regex with colon", filters: []string{`regex:v:(\d)`}, value: "v:2", expected: "2"},
+		{name: "trimprefix", filters: []string{"trimprefix:Bearer "}, value: "Bearer abc", expected: "abc"},
+		{name: "trimsuffix", filters: []string{"trimsuffix:.example.com"}, value: "api.example.com", expected: "api"},
+		{
+			name:     "chain",
+			filters:  []string{"trim", "lower", "sha256", "substring:0:8"},
+			value:    " ABC ",
+			expected: "ba7816bf",
+		},
+		{name: "case insensitive name", filters: []string{"Lower"}, value: "FOO", expected: "foo"},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			chain := filter.New(test.filters)
+			require.NoError(t, chain.Validate())
+			assert.Equal(t, test.expected, chain.Apply(test.value))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		filters  []string
+		expected error
+	}{
+		{name: "unknown filter", filters: []string{"lower", "reverse"}, expected: types.ErrInvalidFilter},
+		{name: "missing argument", filters: []string{"substring"}, expected: types.ErrInvalidFilter},
+		{name: "unexpected argument", filters: []string{"lower:x"}, expected: types.ErrInvalidFilter},
+		{name: "invalid substring start", filters: []string{"substring:a"}, expected: types.ErrInvalidFilter},
+		{name: "invalid substring end", filters: []string{"substring:4:2"}, expected: types.ErrInvalidFilter},
+		{name: "invalid regex", filters: []string{"regex:("}, expected: types.ErrInvalidRegexp},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := filter.New(test.filters).Validate()
+			assert.Equalf(t, true, errors.Is(err, test.expected), "error %v", err)
+		})
+	}
+}
@@ -2,30 +2,59 @@ package add
 
 import (
 	"net/http"
-	"strings"
 
+	"github.com/tomMoulard/htransformation/pkg/filter"
 	"github.com/tomMoulard/htransformation/pkg/types"
 	"github.com/tomMoulard/htransformation/pkg/utils/header"
+	"github.com/tomMoulard/htransformation/pkg/value"
 )
 
 type Add struct {
-	rule *types.Rule
+	rule    *types.Rule
+	value   value.Value
+	filters *filter.Chain
 }
 
 func New(rule types.Rule) (types.Handler, error) {
-	return &Add{rule: &rule}, nil
+	// Templates and references are only compiled when the rule opts in, so that
+	// existing Add rules keep their meaning.
+	ruleValue := value.NewPlain(rule.Value, rule.HeaderPrefix)
+
+	if rule.Interpolate {
+		var err error
+
+		ruleValue, err = value.New(rule.Value, rule.HeaderPrefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Add{rule: &rule, value: ruleValue, filters: filter.New(rule.Filters)}, nil
 }
 
 func (a *Add) Validate() error {
+	if err := a.filters.Validate(); err != nil {
+		return err
+	}
+
 	if a.rule.Header == "" {
 		return types.ErrMissingRequiredFields
 	}
 
+	if !a.rule.SetOnResponse && value.ReferencesResponse(a.value) {
+		return types.ErrResponseOnly
+	}
+
 	return nil
 }
 
 func (a *Add) Handle(rw http.ResponseWriter, req *http.Request) {
-	value := getValue(a.rule.Value, a.rule.HeaderPrefix, req)
+	value, ok := a.value.Resolve(rw, req)
+	if !ok {
+		return
+	}
+
+	value = a.filters.Apply(value)
 
 	if a.rule.SetOnResponse {
 		rw.Header().Add(a.rule.Header, value)
@@ -35,29 +64,3 @@ func (a *Add) Handle(rw http.ResponseWriter, req *http.Request) {
 
 	header.Add(req, a.rule.Header, value)
 }
-
-// getValue checks if prefix exists, the given prefix is present,
-// and then proceeds to read the existing header (after stripping the prefix)
-// to return as value.
-func getValue(ruleValue, valueIsHeaderPrefix string, req *http.Request) string {
-	actualValue := ruleValue
-
-	if valueIsHeaderPrefix != "" && strings.HasPrefix(ruleValue, valueIsHeaderPrefix) {
-		header := strings.TrimPrefix(ruleValue, valueIsHeaderPrefix)
-		// If the resulting value after removing the prefix is empty,
-		// we return the actual value,
-		// which is the prefix itself.
-		// This is because doing a req.Header.Get("") would not fly well.
-		if header == "" {
-			return actualValue
-		}
-
-		if strings.EqualFold(header, "Host") {
-			actualValue = req.Host
-		} else {
-			actualValue = req.Header.Get(header)
-		}
-	}
-
-	return actualValue
-}
@@ -0,0 +1,116 @@
+package basicauth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+	"github.com/tomMoulard/htransformation/pkg/value"
+)
+
+const basicScheme = "Basic "
+
+type BasicAuth struct {
+	rule *types.Rule
+	// identity is written into the rule header, the username by default.
+	identity value.Value
+	// authorization replaces the Authorization header when it is set.
+	authorization value.Value
+	filters       *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	identity := rule.Value
+	interpolate := rule.Interpolate
+
+	if identity == "" {
+		identity, interpolate = "{{ .User }}", true
+	}
+
+	identityValue, err := compile(identity, rule.HeaderPrefix, interpolate)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &BasicAuth{rule: &rule, identity: identityValue, filters: filter.New(rule.Filters)}
+
+	if rule.ValueReplace != "" {
+		handler.authorization, err = compile(rule.ValueReplace, rule.HeaderPrefix, rule.Interpolate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return handler, nil
+}
+
+// compile returns raw as a template or with references when interpolate is
+// set, and as a header reference or a literal otherwise.
+func compile(raw, headerPrefix string, interpolate bool) (value.Value, error) {
+	if !interpolate {
+		return value.NewPlain(raw, headerPrefix), nil
+	}
+
+	return value.New(raw, headerPrefix)
+}
+
+func (b *BasicAuth) Validate() error {
+	if err := b.filters.Validate(); err != nil {
+		return err
+	}
+
+	if b.rule.Header == "" {
+		return types.ErrMissingRequiredFields
+	}
+
+	if b.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	if value.ReferencesResponse(b.identity) || value.ReferencesResponse(b.authorization) {
+		return types.ErrResponseOnly
+	}
+
+	switch b.rule.OnFailure {
+	case "", types.Skip, types.Reject:
+		return nil
+	default:
+		return types.ErrInvalidFailureAction
+	}
+}
+
+func (b *BasicAuth) Handle(rw http.ResponseWriter, req *http.Request) {
+	// The identity header is always removed first, so that clients cannot spoof it.
+	header.Delete(req, b.rule.Header)
+
+	authorization := req.Header.Get("Authorization")
+	if len(authorization) < len(basicScheme) || !strings.EqualFold(authorization[:len(basicScheme)], basicScheme) {
+		return
+	}
+
+	if _, _, ok := req.BasicAuth(); ok {
+		if identity, ok := b.identity.Resolve(rw, req); ok {
+			header.Set(req, b.rule.Header, b.filters.Apply(identity))
+		}
+	} else if b.rule.OnFailure == types.Reject {
+		types.RejectRequest(req, b.rule.RejectStatus, http.StatusUnauthorized)
+
+		return
+	}
+
+	// Credentials are stripped even when they are malformed, or when the
+	// replacement cannot be rendered, so that they never reach the backend.
+	if b.authorization != nil {
+		if authorization, ok := b.authorization.Resolve(rw, req); ok {
+			req.Header.Set("Authorization", authorization)
+
+			return
+		}
+	}
+
+	if b.authorization != nil || b.rule.RemoveSource {
+		req.Header.Del("Authorization")
+	}
+}
@@ -0,0 +1,180 @@
+package basicauth_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/basicauth"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestBasicAuthHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		rule                 types.Rule
+		authorization        string
+		requestHeaders       map[string]string
+		expectedHeaders      map[string]string
+		expectedRejectStatus int
+	}{
+		{
+			name:          "forward username",
+			rule:          types.Rule{Header: "X-Auth-User"},
+			authorization: "Basic amFuZTpzM2NyM3Q=", // jane:s3cr3t
+			expectedHeaders: map[string]string{
+				"X-Auth-User":   "jane",
+				"Authorization": "Basic amFuZTpzM2NyM3Q=",
+			},
+		},
+		{
+			name:          "strip credentials",
+			rule:          types.Rule{Header: "X-Auth-User", RemoveSource: true},
+			authorization: "basic amFuZTpzM2NyM3Q=",
+			expectedHeaders: map[string]string{
+				"X-Auth-User":   "jane",
+				"Authorization": "",
+			},
+		},
+		{
+			name: "templated identity and replaced credentials",
+			rule: types.Rule{
+				Header:       "X-Auth-User",
+				Value:        "{{ .User }}@{{ .Host }}",
+				ValueReplace: "Bearer gateway-token",
+				Interpolate:  true,
+			},
+			authorization: "Basic amFuZTpzM2NyM3Q=",
+			expectedHeaders: map[string]string{
+				"X-Auth-User":   "jane@example.com",
+				"Authorization": "Bearer gateway-token",
+			},
+		},
+		{
+			name:          "other scheme is ignored",
+			rule:          types.Rule{Header: "X-Auth-User", RemoveSource: true, OnFailure: types.Reject},
+			authorization: "Bearer abc",
+			expectedHeaders: map[string]string{
+				"X-Auth-User":   "",
+				"Authorization": "Bearer abc",
+			},
+		},
+		{
+			name: "spoofed identity is removed",
+			rule: types.Rule{Header: "X-Auth-User"},
+			requestHeaders: map[string]string{
+				"X-Auth-User": "admin",
+			},
+			expectedHeaders: map[string]string{
+				"X-Auth-User": "",
+			},
+		},
+		{
+			name:          "malformed credentials skipped",
+			rule:          types.Rule{Header: "X-Auth-User", RemoveSource: true},
+			authorization: "Basic not base64!",
+			expectedHeaders: map[string]string{
+				"X-Auth-User":   "",
+				"Authorization": "",
+			},
+		},
+		{
+			name:                 "malformed credentials rejected",
+			rule:                 types.Rule{Header: "X-Auth-User", OnFailure: types.Reject},
+			authorization:        "Basic amFuZQ==", // jane, without password separator
+			expectedRejectStatus: http.StatusUnauthorized,
+		},
+		{
+			name:                 "malformed credentials rejected with status",
+			rule:                 types.Rule{Header: "X-Auth-User", OnFailure: types.Reject, RejectStatus: http.StatusBadRequest},
+			authorization:        "Basic ???",
+			expectedRejectStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+
+			for hName, hVal := range test.requestHeaders {
+				req.Header.Set(hName, hVal)
+			}
+
+			state := &types.State{}
+			req = types.WithState(req, state)
+
+			handler, err := basicauth.New(test.rule)
+			require.NoError(t, err)
+
+			handler.Handle(nil, req)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equalf(t, hVal, req.Header.Get(hName), "header %q", hName)
+			}
+
+			assert.Equal(t, test.expectedRejectStatus, state.RejectStatus)
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "missing header",
+			rule:    types.Rule{},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{Header: "X-Auth-User", SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "response header in template",
+			rule:    types.Rule{Header: "X-Auth-User", Value: `{{ .User }}@{{ header "resp:X-Realm" }}`, Interpolate: true},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported failure action",
+			rule:    types.Rule{Header: "X-Auth-User", OnFailure: types.Default},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Header: "X-Auth-User", OnFailure: types.Reject},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := basicauth.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package clientcert
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+const defaultPrefix = "X-Client-Cert"
+
+// fields maps the field names, used as header suffixes, to their value.
+var fields = map[string]func(cert *x509.Certificate, sep string) string{
+	"Subject-CN": func(cert *x509.Certificate, _ string) string { return cert.Subject.CommonName },
+	"Subject-O":  func(cert *x509.Certificate, sep string) string { return strings.Join(cert.Subject.Organization, sep) },
+	"SAN-DNS":    func(cert *x509.Certificate, sep string) string { return strings.Join(cert.DNSNames, sep) },
+	"SAN-URI": func(cert *x509.Certificate, sep string) string {
+		uris := make([]string, 0, len(cert.URIs))
+		for _, uri := range cert.URIs {
+			uris = append(uris, uri.String())
+		}
+
+		return strings.Join(uris, sep)
+	},
+	"SAN-Email":  func(cert *x509.Certificate, sep string) string { return strings.Join(cert.EmailAddresses, sep) },
+	"Serial":     func(cert *x509.Certificate, _ string) string { return strings.ToUpper(cert.SerialNumber.Text(16)) },
+	"Issuer":     func(cert *x509.Certificate, _ string) string { return cert.Issuer.String() },
+	"Not-Before": func(cert *x509.Certificate, _ string) string { return cert.NotBefore.UTC().Format(time.RFC3339) },
+	"Not-After":  func(cert *x509.Certificate, _ string) string { return cert.NotAfter.UTC().Format(time.RFC3339) },
+	"Fingerprint-SHA256": func(cert *x509.Certificate, _ string) string {
+		sum := sha256.Sum256(cert.Raw)
+
+		return hex.EncodeToString(sum[:])
+	},
+	// PEM is URL-escaped, as headers cannot hold new lines.
+	"PEM": func(cert *x509.Certificate, _ string) string {
+		return url.QueryEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	},
+}
+
+type ClientCert struct {
+	rule   *types.Rule
+	prefix string
+	// selected are the fields written on the request, all of them by default.
+	selected []string
+	filters  *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	prefix := rule.Header
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+
+	selected := rule.Values
+	if len(selected) == 0 {
+		for field := range fields {
+			selected = append(selected, field)
+		}
+	}
+
+	return &ClientCert{rule: &rule, prefix: prefix, selected: selected, filters: filter.New(rule.Filters)}, nil
+}
+
+func (c *ClientCert) Validate() error {
+	if err := c.filters.Validate(); err != nil {
+		return err
+	}
+
+	if c.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	for _, field := range c.selected {
+		if _, ok := fields[field]; !ok {
+			return fmt.Errorf("%w: %q", types.ErrUnknownField, field)
+		}
+	}
+
+	return nil
+}
+
+func (c *ClientCert) Handle(_ http.ResponseWriter, req *http.Request) {
+	// Every certificate header is removed first, so that clients cannot spoof them.
+	for field := range fields {
+		req.Header.Del(c.prefix + "-" + field)
+	}
+
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return
+	}
+
+	sep := c.rule.Sep
+	if sep == "" {
+		sep = ","
+	}
+
+	leaf := req.TLS.PeerCertificates[0]
+
+	for _, field := range c.selected {
+		if value := fields[field](leaf, sep); value != "" {
+			req.Header.Set(c.prefix+"-"+field, c.filters.Apply(value))
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package clientcert_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/clientcert"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+var (
+	notBefore = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter  = time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// clientCertificate returns a client certificate signed by a throwaway CA.
+func clientCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA", Organization: []string{"Acme CA"}},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	spiffe, err := url.Parse("spiffe://example.com/client")
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(0x1234abcd),
+		Subject:        pkix.Name{CommonName: "client", Organization: []string{"Acme", "Acme Labs"}},
+		NotBefore:      notBefore,
+		NotAfter:       notAfter,
+		DNSNames:       []string{"client.example.com", "client.example.org"},
+		URIs:           []*url.URL{spiffe},
+		EmailAddresses: []string{"client@example.com"},
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// serve runs rule behind a TLS server and returns the request headers seen by the backend.
+func serve(t *testing.T, rule types.Rule, certs []tls.Certificate, headers map[string]string) http.Header {
+	t.Helper()
+
+	handler, err := clientcert.New(rule)
+	require.NoError(t, err)
+	require.NoError(t, handler.Validate())
+
+	var received http.Header
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		handler.Handle(rw, req)
+		received = req.Header.Clone()
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	client := server.Client()
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("unexpected transport %T", client.Transport)
+	}
+
+	transport.TLSClientConfig.Certificates = certs
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	for hName, hVal := range headers {
+		req.Header.Set(hName, hVal)
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	return received
+}
+
+func TestClientCertHandler(t *testing.T) {
+	t.Parallel()
+
+	cert := clientCertificate(t)
+	fingerprint := sha256.Sum256(cert.Certificate[0])
+
+	received := serve(t, types.Rule{}, []tls.Certificate{cert}, map[string]string{
+		"X-Client-Cert-Subject-CN": "admin",
+	})
+
+	expected := map[string]string{
+		"X-Client-Cert-Subject-Cn":         "client",
+		"X-Client-Cert-Subject-O":          "Acme,Acme Labs",
+		"X-Client-Cert-San-Dns":            "client.example.com,client.example.org",
+		"X-Client-Cert-San-Uri":            "spiffe://example.com/client",
+		"X-Client-Cert-San-Email":          "client@example.com",
+		"X-Client-Cert-Serial":             "1234ABCD",
+		"X-Client-Cert-Issuer":             "CN=Test CA,O=Acme CA",
+		"X-Client-Cert-Not-Before":         "2024-01-01T00:00:00Z",
+		"X-Client-Cert-Not-After":          "2034-01-01T00:00:00Z",
+		"X-Client-Cert-Fingerprint-Sha256": hex.EncodeToString(fingerprint[:]),
+	}
+
+	for hName, hVal := range expected {
+		assert.Equalf(t, hVal, received.Get(hName), "header %q", hName)
+	}
+
+	pemValue, err := url.QueryUnescape(received.Get("X-Client-Cert-Pem"))
+	require.NoError(t, err)
+	assert.Equal(t, true, strings.HasPrefix(pemValue, "-----BEGIN CERTIFICATE-----\n"))
+}
+
+func TestClientCertHandlerSelectedFields(t *testing.T) {
+	t.Parallel()
+
+	received := serve(t, types.Rule{
+		Header: "X-SSL",
+		Values: []string{"Subject-CN", "SAN-DNS"},
+		Sep:    " ",
+	}, []tls.Certificate{clientCertificate(t)}, nil)
+
+	assert.Equal(t, "client", received.Get("X-SSL-Subject-CN"))
+	assert.Equal(t, "client.example.com client.example.org", received.Get("X-SSL-SAN-DNS"))
+	assert.Equal(t, "", received.Get("X-SSL-Serial"))
+}
+
+func TestClientCertHandlerWithoutCertificate(t *testing.T) {
+	t.Parallel()
+
+	received := serve(t, types.Rule{}, nil, map[string]string{
+		"X-Client-Cert-Subject-CN": "admin",
+		"X-Client-Cert-PEM":        "forged",
+	})
+
+	assert.Equal(t, "", received.Get("X-Client-Cert-Subject-CN"))
+	assert.Equal(t, "", received.Get("X-Client-Cert-PEM"))
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "all fields by default",
+			rule:    types.Rule{},
+			wantErr: false,
+		},
+		{
+			name:    "unknown field",
+			rule:    types.Rule{Values: []string{"Subject-CN", "Password"}},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{SetOnResponse: true},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := clientcert.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
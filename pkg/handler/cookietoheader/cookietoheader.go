@@ -0,0 +1,95 @@
+package cookietoheader
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+)
+
+type CookieToHeader struct {
+	rule    *types.Rule
+	filters *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	return &CookieToHeader{rule: &rule, filters: filter.New(rule.Filters)}, nil
+}
+
+func (c *CookieToHeader) Validate() error {
+	if err := c.filters.Validate(); err != nil {
+		return err
+	}
+
+	if c.rule.Cookie == "" || c.rule.Header == "" {
+		return types.ErrMissingRequiredFields
+	}
+
+	if c.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	switch c.rule.OnFailure {
+	case "", types.Skip, types.Default, types.Reject:
+		return nil
+	default:
+		return types.ErrInvalidFailureAction
+	}
+}
+
+func (c *CookieToHeader) Handle(_ http.ResponseWriter, req *http.Request) {
+	cookie, err := req.Cookie(c.rule.Cookie)
+	if err != nil {
+		c.handleMissing(req)
+
+		return
+	}
+
+	header.Set(req, c.rule.Header, c.filters.Apply(cookie.Value))
+
+	if c.rule.RemoveSource {
+		removeCookie(req, c.rule.Cookie)
+	}
+}
+
+func (c *CookieToHeader) handleMissing(req *http.Request) {
+	switch c.rule.OnFailure {
+	case types.Default:
+		header.Set(req, c.rule.Header, c.filters.Apply(c.rule.Value))
+	case types.Reject:
+		types.RejectRequest(req, c.rule.RejectStatus, http.StatusBadRequest)
+	case "", types.Skip:
+	}
+}
+
+// removeCookie rewrites the Cookie headers without the named cookie. The
+// headers are filtered as received, the other cookies being kept as is even
+// when they are not valid according to RFC 6265.
+func removeCookie(req *http.Request, name string) {
+	lines := req.Header.Values("Cookie")
+	kept := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		pairs := strings.Split(line, ";")
+		keptPairs := make([]string, 0, len(pairs))
+
+		for _, pair := range pairs {
+			pairName, _, _ := strings.Cut(pair, "=")
+			if strings.TrimSpace(pairName) != name {
+				keptPairs = append(keptPairs, pair)
+			}
+		}
+
+		if filtered := strings.TrimSpace(strings.Join(keptPairs, ";")); filtered != "" {
+			kept = append(kept, filtered)
+		}
+	}
+
+	req.Header.Del("Cookie")
+
+	for _, line := range kept {
+		req.Header.Add("Cookie", line)
+	}
+}
@@ -0,0 +1,212 @@
+package cookietoheader_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/cookietoheader"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestCookieToHeaderHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		rule                 types.Rule
+		cookie               string
+		expectedHeaders      map[string][]string
+		expectedRejectStatus int
+	}{
+		{
+			name: "copy cookie",
+			rule: types.Rule{
+				Cookie: "csrf",
+				Header: "X-CSRF-Token",
+			},
+			cookie: "csrf=t0k3n; tenant=acme",
+			expectedHeaders: map[string][]string{
+				"X-Csrf-Token": {"t0k3n"},
+				"Cookie":       {"csrf=t0k3n; tenant=acme"},
+			},
+		},
+		{
+			name: "move cookie",
+			rule: types.Rule{
+				Cookie:       "csrf",
+				Header:       "X-CSRF-Token",
+				RemoveSource: true,
+			},
+			cookie: "session=abc; csrf=t0k3n; tenant=acme",
+			expectedHeaders: map[string][]string{
+				"X-Csrf-Token": {"t0k3n"},
+				"Cookie":       {"session=abc; tenant=acme"},
+			},
+		},
+		{
+			name: "move only cookie",
+			rule: types.Rule{
+				Cookie:       "tenant",
+				Header:       "X-Tenant",
+				RemoveSource: true,
+			},
+			cookie: "tenant=acme",
+			expectedHeaders: map[string][]string{
+				"X-Tenant": {"acme"},
+				"Cookie":   nil,
+			},
+		},
+		{
+			name: "move cookie keeps other cookies as is",
+			rule: types.Rule{
+				Cookie:       "csrf",
+				Header:       "X-CSRF-Token",
+				RemoveSource: true,
+			},
+			cookie: `csrf=t; a="x y"; b=c,d; bad cookie=1; e={"j":1}`,
+			expectedHeaders: map[string][]string{
+				"X-Csrf-Token": {"t"},
+				"Cookie":       {`a="x y"; b=c,d; bad cookie=1; e={"j":1}`},
+			},
+		},
+		{
+			name: "move last cookie",
+			rule: types.Rule{
+				Cookie:       "csrf",
+				Header:       "X-CSRF-Token",
+				RemoveSource: true,
+			},
+			cookie: "session=abc;csrf=t0k3n",
+			expectedHeaders: map[string][]string{
+				"X-Csrf-Token": {"t0k3n"},
+				"Cookie":       {"session=abc"},
+			},
+		},
+		{
+			name: "missing cookie is skipped",
+			rule: types.Rule{
+				Cookie: "tenant",
+				Header: "X-Tenant",
+			},
+			cookie: "session=abc",
+			expectedHeaders: map[string][]string{
+				"X-Tenant": nil,
+			},
+		},
+		{
+			name: "missing cookie with default",
+			rule: types.Rule{
+				Cookie:    "tenant",
+				Header:    "X-Tenant",
+				Value:     "public",
+				OnFailure: types.Default,
+			},
+			expectedHeaders: map[string][]string{
+				"X-Tenant": {"public"},
+			},
+		},
+		{
+			name: "missing cookie rejected",
+			rule: types.Rule{
+				Cookie:    "csrf",
+				Header:    "X-CSRF-Token",
+				OnFailure: types.Reject,
+			},
+			expectedHeaders: map[string][]string{
+				"X-Csrf-Token": nil,
+			},
+			expectedRejectStatus: http.StatusBadRequest,
+		},
+		{
+			name: "missing cookie rejected with status",
+			rule: types.Rule{
+				Cookie:       "csrf",
+				Header:       "X-CSRF-Token",
+				OnFailure:    types.Reject,
+				RejectStatus: http.StatusForbidden,
+			},
+			expectedRejectStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			if test.cookie != "" {
+				req.Header.Set("Cookie", test.cookie)
+			}
+
+			state := &types.State{}
+			req = types.WithState(req, state)
+
+			handler, err := cookietoheader.New(test.rule)
+			require.NoError(t, err)
+
+			handler.Handle(nil, req)
+
+			for hName, hVals := range test.expectedHeaders {
+				assert.Equalf(t, hVals, req.Header.Values(hName), "header %q", hName)
+			}
+
+			assert.Equal(t, test.expectedRejectStatus, state.RejectStatus)
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "missing cookie",
+			rule:    types.Rule{Header: "X-Tenant"},
+			wantErr: true,
+		},
+		{
+			name:    "missing header",
+			rule:    types.Rule{Cookie: "tenant"},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{Cookie: "tenant", Header: "X-Tenant", SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "unknown failure action",
+			rule:    types.Rule{Cookie: "tenant", Header: "X-Tenant", OnFailure: "Explode"},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Cookie: "tenant", Header: "X-Tenant", OnFailure: types.Reject},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := cookietoheader.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package deleter
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/tomMoulard/htransformation/pkg/types"
@@ -16,6 +17,10 @@ func New(rule types.Rule) (types.Handler, error) {
 }
 
 func (d *Deleter) Validate() error {
+	if len(d.rule.Filters) > 0 {
+		return fmt.Errorf("%w: not supported by Del rules", types.ErrInvalidFilter)
+	}
+
 	return nil
 }
 
@@ -207,6 +207,15 @@ func TestValidation(t *testing.T) {
 			name:    "no rules",
 			wantErr: false,
 		},
+		{
+			name: "filters",
+			rule: types.Rule{
+				Header:  "not-empty",
+				Type:    types.Delete,
+				Filters: []string{"lower"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid rule",
 			rule: types.Rule{
@@ -0,0 +1,89 @@
+package extract
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+	"github.com/tomMoulard/htransformation/pkg/value"
+)
+
+// Without ValueReplace, the first group of the regexp is written, or the whole
+// match when it has no group.
+const (
+	defaultTemplate        = "$1"
+	defaultTemplateNoGroup = "$0"
+)
+
+// Extract copies the part of the From header matched by a regexp into Header.
+type Extract struct {
+	rule     *types.Rule
+	regexp   *regexp.Regexp
+	template string
+	source   value.Source
+	filters  *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	reg, err := regexp.Compile(rule.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %q", types.ErrInvalidRegexp, rule.Name, rule.Value)
+	}
+
+	template := rule.ValueReplace
+	if template == "" {
+		template = defaultTemplate
+		if reg.NumSubexp() == 0 {
+			template = defaultTemplateNoGroup
+		}
+	}
+
+	return &Extract{
+		rule:     &rule,
+		regexp:   reg,
+		template: template,
+		source:   value.NewSource(rule.From),
+		filters:  filter.New(rule.Filters),
+	}, nil
+}
+
+func (e *Extract) Validate() error {
+	if err := e.filters.Validate(); err != nil {
+		return err
+	}
+
+	if e.rule.From == "" || e.rule.Header == "" || e.rule.Value == "" {
+		return types.ErrMissingRequiredFields
+	}
+
+	if !e.rule.SetOnResponse && e.source.Response() {
+		return types.ErrResponseOnly
+	}
+
+	return nil
+}
+
+func (e *Extract) Handle(rw http.ResponseWriter, req *http.Request) {
+	source, ok := e.source.Lookup(rw, req)
+	if !ok {
+		return
+	}
+
+	match := e.regexp.FindStringSubmatchIndex(source)
+	if match == nil {
+		return
+	}
+
+	value := e.filters.Apply(string(e.regexp.ExpandString(nil, e.template, source, match)))
+
+	if e.rule.SetOnResponse {
+		rw.Header().Set(e.rule.Header, value)
+
+		return
+	}
+
+	header.Set(req, e.rule.Header, value)
+}
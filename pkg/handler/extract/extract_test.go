@@ -0,0 +1,299 @@
+package extract_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/extract"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestExtractHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		rule            types.Rule
+		requestHeaders  map[string]string
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "numbered group",
+			rule: types.Rule{
+				From:         "Accept",
+				Header:       "X-Api-Version",
+				Value:        `^application/vnd\.acme\.v(\d+)\+json$`,
+				ValueReplace: "v$1",
+			},
+			requestHeaders: map[string]string{
+				"Accept": "application/vnd.acme.v2+json",
+			},
+			expectedHeaders: map[string]string{
+				"Accept":        "application/vnd.acme.v2+json",
+				"X-Api-Version": "v2",
+			},
+		},
+		{
+			name: "named groups",
+			rule: types.Rule{
+				From:         "X-Original-Uri",
+				Header:       "X-Resource",
+				Value:        `^/(?P<collection>[a-z]+)/(?P<id>\d+)`,
+				ValueReplace: "${collection}:${id}",
+			},
+			requestHeaders: map[string]string{
+				"X-Original-Uri": "/orders/42/items",
+			},
+			expectedHeaders: map[string]string{
+				"X-Original-Uri": "/orders/42/items",
+				"X-Resource":     "orders:42",
+			},
+		},
+		{
+			name: "first group by default",
+			rule: types.Rule{
+				From:   "X-Forwarded-For",
+				Header: "X-Client-Ip",
+				Value:  `^\s*([^,\s]+)`,
+			},
+			requestHeaders: map[string]string{
+				"X-Forwarded-For": "203.0.113.7, 10.0.0.1",
+			},
+			expectedHeaders: map[string]string{
+				"X-Client-Ip": "203.0.113.7",
+			},
+		},
+		{
+			name: "whole match without group",
+			rule: types.Rule{
+				From:   "User-Agent",
+				Header: "X-Client-Version",
+				Value:  `\d+\.\d+\.\d+`,
+			},
+			requestHeaders: map[string]string{
+				"User-Agent": "acme-cli/1.12.3 (linux)",
+			},
+			expectedHeaders: map[string]string{
+				"X-Client-Version": "1.12.3",
+			},
+		},
+		{
+			name: "no match leaves the header unchanged",
+			rule: types.Rule{
+				From:   "Accept",
+				Header: "X-Api-Version",
+				Value:  `vnd\.acme\.v(\d+)`,
+			},
+			requestHeaders: map[string]string{
+				"Accept":        "application/json",
+				"X-Api-Version": "v1",
+			},
+			expectedHeaders: map[string]string{
+				"X-Api-Version": "v1",
+			},
+		},
+		{
+			name: "missing source",
+			rule: types.Rule{
+				From:   "Accept",
+				Header: "X-Api-Version",
+				Value:  `vnd\.acme\.v(\d+)`,
+			},
+			expectedHeaders: map[string]string{
+				"X-Api-Version": "",
+			},
+		},
+		{
+			name: "filtered value",
+			rule: types.Rule{
+				From:    "X-Tenant-Name",
+				Header:  "X-Tenant",
+				Value:   `^tenant-(.+)$`,
+				Filters: []string{"lower"},
+			},
+			requestHeaders: map[string]string{
+				"X-Tenant-Name": "tenant-ACME",
+			},
+			expectedHeaders: map[string]string{
+				"X-Tenant": "acme",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			for hName, hVal := range test.requestHeaders {
+				req.Header.Set(hName, hVal)
+			}
+
+			handler, err := extract.New(test.rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			handler.Handle(nil, req)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equal(t, hVal, req.Header.Get(hName))
+			}
+		})
+	}
+}
+
+func TestExtractHost(t *testing.T) {
+	t.Parallel()
+
+	handler, err := extract.New(types.Rule{
+		From:   "Host",
+		Header: "X-Tenant",
+		Value:  `^([a-z0-9-]+)\.example\.com$`,
+	})
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://acme.example.com/foo", nil)
+	require.NoError(t, err)
+
+	handler.Handle(nil, req)
+
+	assert.Equal(t, "acme", req.Header.Get("X-Tenant"))
+	assert.Equal(t, "acme.example.com", req.Host)
+}
+
+func TestExtractResponse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		from     string
+		expected string
+	}{
+		{
+			name:     "response header",
+			from:     "resp:Content-Type",
+			expected: "utf-8",
+		},
+		{
+			name:     "request header",
+			from:     "req:Content-Type",
+			expected: "latin1",
+		},
+		{
+			name:     "request header without namespace",
+			from:     "Content-Type",
+			expected: "latin1",
+		},
+		{
+			name:     "original request header",
+			from:     "original:Content-Type",
+			expected: "ascii",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := extract.New(types.Rule{
+				From:          test.from,
+				Header:        "X-Charset",
+				Value:         `charset=([^;]+)`,
+				SetOnResponse: true,
+			})
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", "text/plain; charset=ascii")
+
+			state := &types.State{}
+			state.SaveOriginal(req)
+			req = types.WithState(req, state)
+			req.Header.Set("Content-Type", "text/plain; charset=latin1")
+
+			rw := httptest.NewRecorder()
+			rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+			handler.Handle(rw, req)
+
+			assert.Equal(t, test.expected, rw.Header().Get("X-Charset"))
+			assert.Equal(t, "text/html; charset=utf-8", rw.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		rule       types.Rule
+		wantErr    bool
+		wantNewErr bool
+	}{
+		{
+			name:    "missing from",
+			rule:    types.Rule{Header: "X-Tenant", Value: "^(.+)$"},
+			wantErr: true,
+		},
+		{
+			name:    "missing header",
+			rule:    types.Rule{From: "Host", Value: "^(.+)$"},
+			wantErr: true,
+		},
+		{
+			name:    "missing regexp",
+			rule:    types.Rule{From: "Host", Header: "X-Tenant"},
+			wantErr: true,
+		},
+		{
+			name:       "invalid regexp",
+			rule:       types.Rule{From: "Host", Header: "X-Tenant", Value: "^(.+$"},
+			wantNewErr: true,
+		},
+		{
+			name:    "unknown filter",
+			rule:    types.Rule{From: "Host", Header: "X-Tenant", Value: "^(.+)$", Filters: []string{"reverse"}},
+			wantErr: true,
+		},
+		{
+			name:    "response header on request",
+			rule:    types.Rule{From: "resp:Content-Type", Header: "X-Charset", Value: "charset=(.+)"},
+			wantErr: true,
+		},
+		{
+			name: "valid rule",
+			rule: types.Rule{From: "Host", Header: "X-Tenant", Value: "^(.+)$", ValueReplace: "tenant-$1"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := extract.New(test.rule)
+			if test.wantNewErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
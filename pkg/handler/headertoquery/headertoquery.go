@@ -0,0 +1,57 @@
+package headertoquery
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+	"github.com/tomMoulard/htransformation/pkg/utils/query"
+)
+
+type HeaderToQuery struct {
+	rule    *types.Rule
+	filters *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	return &HeaderToQuery{rule: &rule, filters: filter.New(rule.Filters)}, nil
+}
+
+func (h *HeaderToQuery) Validate() error {
+	if err := h.filters.Validate(); err != nil {
+		return err
+	}
+
+	if h.rule.Param == "" || h.rule.Header == "" {
+		return types.ErrMissingRequiredFields
+	}
+
+	if h.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	return nil
+}
+
+func (h *HeaderToQuery) Handle(_ http.ResponseWriter, req *http.Request) {
+	var value string
+
+	if strings.EqualFold(h.rule.Header, "Host") {
+		value = req.Host
+	} else {
+		values := req.Header.Values(h.rule.Header)
+		if len(values) == 0 {
+			return
+		}
+
+		value = values[0]
+	}
+
+	req.URL.RawQuery = query.Set(req.URL.RawQuery, h.rule.Param, h.filters.Apply(value))
+
+	if h.rule.RemoveSource {
+		header.Delete(req, h.rule.Header)
+	}
+}
@@ -0,0 +1,151 @@
+package headertoquery_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/headertoquery"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestHeaderToQueryHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		rule             types.Rule
+		requestHeaders   map[string]string
+		expectedHeaders  map[string]string
+		expectedRawQuery string
+	}{
+		{
+			name: "copy header",
+			rule: types.Rule{
+				Header: "X-Api-Key",
+				Param:  "api_key",
+			},
+			requestHeaders: map[string]string{
+				"X-Api-Key": "s3cr3t",
+			},
+			expectedHeaders: map[string]string{
+				"X-Api-Key": "s3cr3t",
+			},
+			expectedRawQuery: "page=2&api_key=s3cr3t",
+		},
+		{
+			name: "move header",
+			rule: types.Rule{
+				Header:       "X-Api-Key",
+				Param:        "api_key",
+				RemoveSource: true,
+			},
+			requestHeaders: map[string]string{
+				"X-Api-Key": "a b",
+			},
+			expectedHeaders: map[string]string{
+				"X-Api-Key": "",
+			},
+			expectedRawQuery: "page=2&api_key=a+b",
+		},
+		{
+			name: "replace existing parameter",
+			rule: types.Rule{
+				Header: "X-Page",
+				Param:  "page",
+			},
+			requestHeaders: map[string]string{
+				"X-Page": "3",
+			},
+			expectedRawQuery: "page=3",
+		},
+		{
+			name: "host header",
+			rule: types.Rule{
+				Header: "Host",
+				Param:  "host",
+			},
+			expectedRawQuery: "page=2&host=example.com",
+		},
+		{
+			name: "missing header",
+			rule: types.Rule{
+				Header: "X-Api-Key",
+				Param:  "api_key",
+			},
+			expectedRawQuery: "page=2",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo?page=2", nil)
+			require.NoError(t, err)
+
+			for hName, hVal := range test.requestHeaders {
+				req.Header.Set(hName, hVal)
+			}
+
+			handler, err := headertoquery.New(test.rule)
+			require.NoError(t, err)
+
+			handler.Handle(nil, req)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equal(t, hVal, req.Header.Get(hName))
+			}
+
+			assert.Equal(t, test.expectedRawQuery, req.URL.RawQuery)
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "missing param",
+			rule:    types.Rule{Header: "X-Api-Key"},
+			wantErr: true,
+		},
+		{
+			name:    "missing header",
+			rule:    types.Rule{Param: "api_key"},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{Header: "X-Api-Key", Param: "api_key", SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Header: "X-Api-Key", Param: "api_key"},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := headertoquery.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
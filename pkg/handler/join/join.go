@@ -4,22 +4,41 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/tomMoulard/htransformation/pkg/filter"
 	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/value"
 )
 
 type Join struct {
-	rule *types.Rule
+	rule    *types.Rule
+	values  []value.Value
+	filters *filter.Chain
 }
 
 func New(rule types.Rule) (types.Handler, error) {
-	return &Join{rule: &rule}, nil
+	values, err := value.NewList(rule.Values, rule.HeaderPrefix, rule.Interpolate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Join{rule: &rule, values: values, filters: filter.New(rule.Filters)}, nil
 }
 
 func (j *Join) Validate() error {
+	if err := j.filters.Validate(); err != nil {
+		return err
+	}
+
 	if len(j.rule.Values) == 0 || j.rule.Sep == "" {
 		return types.ErrMissingRequiredFields
 	}
 
+	for _, joined := range j.values {
+		if !j.rule.SetOnResponse && value.ReferencesResponse(joined) {
+			return types.ErrResponseOnly
+		}
+	}
+
 	return nil
 }
 
@@ -37,10 +56,17 @@ func (j *Join) Handle(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	newHeaderVal := val[0]
-	for _, value := range j.rule.Values {
-		newHeaderVal += j.rule.Sep + getValue(value, j.rule.HeaderPrefix, req)
+	for _, value := range j.values {
+		resolved, ok := value.Resolve(rw, req)
+		if !ok {
+			return
+		}
+
+		newHeaderVal += j.rule.Sep + resolved
 	}
 
+	newHeaderVal = j.filters.Apply(newHeaderVal)
+
 	if j.rule.SetOnResponse {
 		rw.Header().Set(j.rule.Name, newHeaderVal)
 
@@ -53,29 +79,3 @@ func (j *Join) Handle(rw http.ResponseWriter, req *http.Request) {
 		req.Header.Set(j.rule.Header, newHeaderVal)
 	}
 }
-
-// getValue checks if prefix exists, the given prefix is present,
-// and then proceeds to read the existing header (after stripping the prefix)
-// to return as value.
-func getValue(ruleValue, valueIsHeaderPrefix string, req *http.Request) string {
-	actualValue := ruleValue
-
-	if valueIsHeaderPrefix != "" && strings.HasPrefix(ruleValue, valueIsHeaderPrefix) {
-		header := strings.TrimPrefix(ruleValue, valueIsHeaderPrefix)
-		// If the resulting value after removing the prefix is empty,
-		// we return the actual value,
-		// which is the prefix itself.
-		// This is because doing a req.Header.Get("") would not fly well.
-		if header == "" {
-			return actualValue
-		}
-
-		if strings.EqualFold(header, "Host") {
-			actualValue = req.Host
-		} else {
-			actualValue = req.Header.Get(header)
-		}
-	}
-
-	return actualValue
-}
@@ -174,6 +174,24 @@ func TestJoinHandler(t *testing.T) {
 			},
 			expectedHost: "example.com,Tested",
 		},
+		{
+			name: "Join templated value",
+			rule: types.Rule{
+				Sep:    ",",
+				Header: "X-Test",
+				Values: []string{
+					"{{ .Method }} {{ .Path }}",
+				},
+				Interpolate: true,
+			},
+			requestHeaders: map[string]string{
+				"X-Test": "Bar",
+			},
+			expectedHeaders: map[string]string{
+				"X-Test": "Bar,GET /foo",
+			},
+			expectedHost: "example.com",
+		},
 		{
 			name: "Twice Host header",
 			rule: types.Rule{
@@ -0,0 +1,261 @@
+package jwtclaims
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+	"github.com/tomMoulard/htransformation/pkg/utils/key"
+)
+
+var errInvalidToken = errors.New("invalid token")
+
+type JWTClaims struct {
+	rule *types.Rule
+	// key is used to verify HS256 signatures.
+	key *key.Key
+	// verify is set when Key or KeyFile is configured; tokens are only decoded otherwise.
+	verify  bool
+	filters *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	verificationKey, err := key.New(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	verify := rule.Key != "" || rule.KeyFile != ""
+	if verify && len(verificationKey.Bytes()) == 0 {
+		return nil, fmt.Errorf("%w: empty key", types.ErrInvalidKey)
+	}
+
+	return &JWTClaims{rule: &rule, key: verificationKey, verify: verify, filters: filter.New(rule.Filters)}, nil
+}
+
+func (j *JWTClaims) Validate() error {
+	if err := j.filters.Validate(); err != nil {
+		return err
+	}
+
+	if len(j.rule.Claims) == 0 {
+		return types.ErrMissingRequiredFields
+	}
+
+	for _, claim := range j.rule.Claims {
+		if claim.Claim == "" || claim.Header == "" {
+			return types.ErrMissingRequiredFields
+		}
+	}
+
+	if j.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	switch j.rule.OnFailure {
+	case "", types.Skip, types.Reject:
+		return nil
+	default:
+		return types.ErrInvalidFailureAction
+	}
+}
+
+func (j *JWTClaims) Handle(_ http.ResponseWriter, req *http.Request) {
+	// Claim headers are always removed first, so that clients cannot spoof them.
+	for _, claim := range j.rule.Claims {
+		header.Delete(req, claim.Header)
+	}
+
+	claims, err := j.parse(j.token(req))
+	if err != nil {
+		if j.rule.OnFailure == types.Reject {
+			types.RejectRequest(req, j.rule.RejectStatus, http.StatusUnauthorized)
+		}
+
+		return
+	}
+
+	sep := j.rule.Sep
+	if sep == "" {
+		sep = ","
+	}
+
+	for _, claim := range j.rule.Claims {
+		if value, ok := lookup(claims, claim.Claim); ok {
+			header.Set(req, claim.Header, j.filters.Apply(format(value, sep)))
+		}
+	}
+
+	if j.rule.RemoveSource {
+		header.Delete(req, j.sourceHeader())
+	}
+}
+
+func (j *JWTClaims) sourceHeader() string {
+	if j.rule.Header != "" {
+		return j.rule.Header
+	}
+
+	return "Authorization"
+}
+
+// token returns the token of the request, without its Bearer scheme.
+func (j *JWTClaims) token(req *http.Request) string {
+	token := strings.TrimSpace(req.Header.Get(j.sourceHeader()))
+	if len(token) > len("Bearer ") && strings.EqualFold(token[:len("Bearer ")], "Bearer ") {
+		token = strings.TrimSpace(token[len("Bearer "):])
+	}
+
+	return token
+}
+
+// parse decodes the claims of token, after verifying it when a key is configured.
+func (j *JWTClaims) parse(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 parts", errInvalidToken)
+	}
+
+	if j.verify {
+		// The key is read once per token, a key file being reloaded when it changes.
+		secret := j.key.Bytes()
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("%w: empty key", errInvalidToken)
+		}
+
+		if err := verifySignature(parts, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	if j.verify {
+		if err := verifyTimes(claims, time.Now().Unix()); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+func verifySignature(parts []string, secret []byte) error {
+	var joseHeader struct {
+		Alg string `json:"alg"`
+	}
+
+	if err := decodeSegment(parts[0], &joseHeader); err != nil {
+		return err
+	}
+
+	if joseHeader.Alg != "HS256" {
+		return fmt.Errorf("%w: unsupported algorithm %q", errInvalidToken, joseHeader.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidToken, err)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return fmt.Errorf("%w: signature mismatch", errInvalidToken)
+	}
+
+	return nil
+}
+
+// verifyTimes checks the exp and nbf claims, when present.
+func verifyTimes(claims map[string]interface{}, now int64) error {
+	if exp, ok := claims["exp"].(json.Number); ok {
+		if expiry, err := exp.Float64(); err != nil || float64(now) >= expiry {
+			return fmt.Errorf("%w: expired", errInvalidToken)
+		}
+	}
+
+	if nbf, ok := claims["nbf"].(json.Number); ok {
+		if notBefore, err := nbf.Float64(); err != nil || float64(now) < notBefore {
+			return fmt.Errorf("%w: not valid yet", errInvalidToken)
+		}
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, target interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidToken, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidToken, err)
+	}
+
+	return nil
+}
+
+// lookup returns the claim at path, nested claims being separated by dots.
+func lookup(claims map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = claims
+
+	for _, name := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if current, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// format returns the header value of a claim, arrays being joined with sep.
+func format(value interface{}, sep string) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case json.Number:
+		return typed.String()
+	case bool:
+		return strconv.FormatBool(typed)
+	case []interface{}:
+		items := make([]string, 0, len(typed))
+		for _, item := range typed {
+			items = append(items, format(item, sep))
+		}
+
+		return strings.Join(items, sep)
+	default:
+		encoded, err := json.Marshal(typed)
+		if err != nil {
+			return ""
+		}
+
+		return string(encoded)
+	}
+}
@@ -0,0 +1,304 @@
+package jwtclaims_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/jwtclaims"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+const payload = `{"sub":"42","email":"jane@example.com","roles":["admin","dev"],` +
+	`"address":{"country":"FR"},"admin":true,"level":3}`
+
+// token builds a JWT signed with HS256 using key, or an unsigned one if key is empty.
+func token(alg, payload, key string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	unsigned := encode([]byte(`{"alg":"`+alg+`","typ":"JWT"}`)) + "." + encode([]byte(payload))
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(unsigned))
+
+	return unsigned + "." + encode(mac.Sum(nil))
+}
+
+func TestJWTClaimsHandler(t *testing.T) {
+	t.Parallel()
+
+	claims := []types.Claim{
+		{Claim: "sub", Header: "X-User-Id"},
+		{Claim: "email", Header: "X-Email"},
+		{Claim: "roles", Header: "X-Roles"},
+		{Claim: "address.country", Header: "X-Country"},
+		{Claim: "admin", Header: "X-Admin"},
+		{Claim: "level", Header: "X-Level"},
+		{Claim: "address", Header: "X-Address"},
+		{Claim: "missing.claim", Header: "X-Missing"},
+	}
+
+	expired := `{"sub":"42","exp":` + strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10) + `}`
+	notExpired := `{"sub":"42","exp":` + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10) + `}`
+
+	testCases := []struct {
+		name                 string
+		rule                 types.Rule
+		requestHeaders       map[string]string
+		expectedHeaders      map[string]string
+		expectedRejectStatus int
+	}{
+		{
+			name: "decode without verification",
+			rule: types.Rule{Claims: claims, Sep: "|"},
+			requestHeaders: map[string]string{
+				"Authorization": "Bearer " + token("none", payload, ""),
+			},
+			expectedHeaders: map[string]string{
+				"Authorization": "Bearer " + token("none", payload, ""),
+				"X-User-Id":     "42",
+				"X-Email":       "jane@example.com",
+				"X-Roles":       "admin|dev",
+				"X-Country":     "FR",
+				"X-Admin":       "true",
+				"X-Level":       "3",
+				"X-Address":     `{"country":"FR"}`,
+				"X-Missing":     "",
+			},
+		},
+		{
+			name: "default separator and source removal",
+			rule: types.Rule{Claims: claims, RemoveSource: true},
+			requestHeaders: map[string]string{
+				"Authorization": "bearer " + token("none", payload, ""),
+			},
+			expectedHeaders: map[string]string{
+				"Authorization": "",
+				"X-Roles":       "admin,dev",
+			},
+		},
+		{
+			name: "custom source header",
+			rule: types.Rule{Claims: claims, Header: "X-Token"},
+			requestHeaders: map[string]string{
+				"X-Token": token("none", payload, ""),
+			},
+			expectedHeaders: map[string]string{
+				"X-User-Id": "42",
+			},
+		},
+		{
+			name: "spoofed headers are removed",
+			rule: types.Rule{Claims: claims},
+			requestHeaders: map[string]string{
+				"X-User-Id": "1",
+				"X-Roles":   "admin",
+			},
+			expectedHeaders: map[string]string{
+				"X-User-Id": "",
+				"X-Roles":   "",
+			},
+		},
+		{
+			name: "valid signature",
+			rule: types.Rule{Claims: claims, Key: "secret", OnFailure: types.Reject},
+			requestHeaders: map[string]string{
+				"Authorization": "Bearer " + token("HS256", notExpired, "secret"),
+			},
+			expectedHeaders: map[string]string{
+				"X-User-Id": "42",
+			},
+		},
+		{
+			name: "invalid signature skipped",
+			rule: types.Rule{Claims: claims, Key: "secret"},
+			requestHeaders: map[string]string{
+				"Authorization": "Bearer " + token("HS256", payload, "other"),
+			},
+			expectedHeaders: map[string]string{
+				"X-User-Id": "",
+			},
+		},
+		{
+			name: "invalid signature rejected",
+			rule: types.Rule{Claims: claims, Key: "secret", OnFailure: types.Reject},
+			requestHeaders: map[string]string{
+				"Authorization": "Bearer " + token("HS256", payload, "other"),
+			},
+			expectedRejectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "unsigned token rejected",
+			rule: types.Rule{Claims: claims, Key: "secret", OnFailure: types.Reject, RejectStatus: http.StatusForbidden},
+			requestHeaders: map[string]string{
+				"Authorization": "Bearer " + token("none", payload, "secret"),
+			},
+			expectedRejectStatus: http.StatusForbidden,
+		},
+		{
+			name: "expired token rejected",
+			rule: types.Rule{Claims: claims, Key: "secret", OnFailure: types.Reject},
+			requestHeaders: map[string]string{
+				"Authorization": "Bearer " + token("HS256", expired, "secret"),
+			},
+			expectedRejectStatus: http.StatusUnauthorized,
+		},
+		{
+			name:                 "missing token rejected",
+			rule:                 types.Rule{Claims: claims, OnFailure: types.Reject},
+			expectedRejectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "malformed token rejected",
+			rule: types.Rule{Claims: claims, OnFailure: types.Reject},
+			requestHeaders: map[string]string{
+				"Authorization": "Bearer not-a-jwt",
+			},
+			expectedRejectStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			for hName, hVal := range test.requestHeaders {
+				req.Header.Set(hName, hVal)
+			}
+
+			state := &types.State{}
+			req = types.WithState(req, state)
+
+			handler, err := jwtclaims.New(test.rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			handler.Handle(nil, req)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equalf(t, hVal, req.Header.Get(hName), "header %q", hName)
+			}
+
+			assert.Equal(t, test.expectedRejectStatus, state.RejectStatus)
+		})
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	t.Parallel()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("secret\n"), 0o600))
+
+	handler, err := jwtclaims.New(types.Rule{
+		Claims:    []types.Claim{{Claim: "sub", Header: "X-User-Id"}},
+		KeyFile:   keyFile,
+		OnFailure: types.Reject,
+	})
+	require.NoError(t, err)
+
+	userID := func(secret string) string {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+		require.NoError(t, err)
+
+		req = types.WithState(req, &types.State{})
+		req.Header.Set("Authorization", "Bearer "+token("HS256", payload, secret))
+
+		handler.Handle(nil, req)
+
+		return req.Header.Get("X-User-Id")
+	}
+
+	assert.Equal(t, "42", userID("secret"))
+
+	// Files are checked for changes once per second.
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.WriteFile(keyFile, []byte("rotated\n"), 0o600))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+	time.Sleep(1100 * time.Millisecond)
+
+	assert.Equal(t, "", userID("secret"))
+	assert.Equal(t, "42", userID("rotated"))
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	claims := []types.Claim{{Claim: "sub", Header: "X-User-Id"}}
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "missing claims",
+			rule:    types.Rule{},
+			wantErr: true,
+		},
+		{
+			name:    "claim without header",
+			rule:    types.Rule{Claims: []types.Claim{{Claim: "sub"}}},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{Claims: claims, SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported failure action",
+			rule:    types.Rule{Claims: claims, OnFailure: types.Default},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Claims: claims, Key: "secret", OnFailure: types.Reject},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := jwtclaims.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEmptyKey(t *testing.T) {
+	t.Setenv("HTRANSFORMATION_TEST_JWT_KEY", "")
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("\n"), 0o600))
+
+	claims := []types.Claim{{Claim: "sub", Header: "X-User-Id"}}
+
+	for _, rule := range []types.Rule{
+		{Claims: claims, Key: "${env:HTRANSFORMATION_TEST_JWT_KEY}", OnFailure: types.Reject},
+		{Claims: claims, KeyFile: keyFile, OnFailure: types.Reject},
+	} {
+		_, err := jwtclaims.New(rule)
+		assert.Equal(t, true, errors.Is(err, types.ErrInvalidKey))
+	}
+}
@@ -0,0 +1,201 @@
+package mapping
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+	"github.com/tomMoulard/htransformation/pkg/utils/table"
+	"github.com/tomMoulard/htransformation/pkg/utils/watch"
+	"github.com/tomMoulard/htransformation/pkg/value"
+)
+
+// tableCheckInterval is how often TableFile is checked for changes.
+const tableCheckInterval = time.Second
+
+// Mapping translates the value of the From header with a lookup table.
+type Mapping struct {
+	rule *types.Rule
+	// table is the compiled Table, nil when the table is read from TableFile.
+	table   *lookupTable
+	file    *watch.File
+	source  value.Source
+	filters *filter.Chain
+}
+
+// lookupTable is a compiled table.
+type lookupTable struct {
+	match types.MatchType
+	// exact maps the keys, lower-cased when match is CaseInsensitive, to their value.
+	exact   map[string]string
+	regexps []entry
+}
+
+// entry is a table entry with its key compiled, when Match is Regexp.
+type entry struct {
+	key   *regexp.Regexp
+	value string
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	mapping := &Mapping{
+		rule:    &rule,
+		source:  value.NewSource(rule.From),
+		filters: filter.New(rule.Filters),
+	}
+
+	if rule.TableFile == "" {
+		lookup, err := compile(rule.Name, rule.Match, rule.Table)
+		if err != nil {
+			return nil, err
+		}
+
+		mapping.table = lookup
+
+		return mapping, nil
+	}
+
+	// The table is compiled once per change of the file, and replaced as a whole.
+	file, err := watch.New(rule.TableFile, tableCheckInterval, func(content []byte) (interface{}, error) {
+		entries, err := table.Parse(rule.TableFile, content)
+		if err != nil {
+			return nil, err
+		}
+
+		return compile(rule.Name, rule.Match, entries)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mapping.file = file
+
+	return mapping, nil
+}
+
+// compile builds the lookup table of entries, the first entry of a key taking precedence.
+func compile(name string, match types.MatchType, entries []types.Mapping) (*lookupTable, error) {
+	lookup := &lookupTable{match: match, exact: make(map[string]string, len(entries))}
+
+	for _, mapped := range entries {
+		key := mapped.Key
+
+		switch match {
+		case types.MatchRegexp:
+			reg, err := regexp.Compile(key)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %q", types.ErrInvalidRegexp, name, key)
+			}
+
+			lookup.regexps = append(lookup.regexps, entry{key: reg, value: mapped.Value})
+
+			continue
+		case types.MatchCaseInsensitive:
+			key = strings.ToLower(key)
+		}
+
+		if _, ok := lookup.exact[key]; !ok {
+			lookup.exact[key] = mapped.Value
+		}
+	}
+
+	return lookup, nil
+}
+
+func (m *Mapping) Validate() error {
+	if err := m.filters.Validate(); err != nil {
+		return err
+	}
+
+	if m.rule.From == "" || m.rule.Header == "" || (len(m.rule.Table) == 0 && m.rule.TableFile == "") {
+		return types.ErrMissingRequiredFields
+	}
+
+	if !m.rule.SetOnResponse && m.source.Response() {
+		return types.ErrResponseOnly
+	}
+
+	if len(m.rule.Table) != 0 && m.rule.TableFile != "" {
+		return fmt.Errorf("%w: %s: Table and TableFile cannot be used together", types.ErrInvalidTable, m.rule.Name)
+	}
+
+	switch m.rule.Match {
+	case "", types.MatchExact, types.MatchCaseInsensitive, types.MatchRegexp:
+	default:
+		return fmt.Errorf("%w: %s: %q", types.ErrInvalidMatch, m.rule.Name, m.rule.Match)
+	}
+
+	switch m.rule.OnFailure {
+	case "", types.Skip, types.Default:
+		return nil
+	default:
+		return types.ErrInvalidFailureAction
+	}
+}
+
+func (m *Mapping) Handle(rw http.ResponseWriter, req *http.Request) {
+	source, found := m.source.Lookup(rw, req)
+
+	mapped, ok := "", false
+	if found {
+		mapped, ok = m.lookupTable().lookup(source)
+	}
+
+	if m.rule.RemoveSource && !strings.EqualFold(m.rule.From, m.rule.Header) {
+		m.source.Delete(rw, req)
+	}
+
+	if !ok {
+		if m.rule.OnFailure != types.Default {
+			return
+		}
+
+		mapped = m.rule.Value
+	}
+
+	mapped = m.filters.Apply(mapped)
+
+	if m.rule.SetOnResponse {
+		rw.Header().Set(m.rule.Header, mapped)
+
+		return
+	}
+
+	header.Set(req, m.rule.Header, mapped)
+}
+
+// lookupTable returns the current table, reloaded from TableFile when it changed.
+func (m *Mapping) lookupTable() *lookupTable {
+	if m.file == nil {
+		return m.table
+	}
+
+	lookup, _ := m.file.Value().(*lookupTable) // the file is parsed into a table.
+
+	return lookup
+}
+
+// lookup returns the value the source maps to, or false when no entry matches.
+func (t *lookupTable) lookup(source string) (string, bool) {
+	switch t.match {
+	case types.MatchRegexp:
+		for _, mapped := range t.regexps {
+			if match := mapped.key.FindStringSubmatchIndex(source); match != nil {
+				return string(mapped.key.ExpandString(nil, mapped.value, source, match)), true
+			}
+		}
+
+		return "", false
+	case types.MatchCaseInsensitive:
+		source = strings.ToLower(source)
+	}
+
+	mapped, ok := t.exact[source]
+
+	return mapped, ok
+}
@@ -0,0 +1,525 @@
+package mapping_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/mapping"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestMapHandler(t *testing.T) {
+	t.Parallel()
+
+	regions := []types.Mapping{
+		{Key: "eu", Value: "fra-1"},
+		{Key: "us", Value: "iad-1"},
+		{Key: "eu", Value: "ams-1"},
+	}
+
+	testCases := []struct {
+		name            string
+		rule            types.Rule
+		requestHeaders  map[string]string
+		expectedHeaders map[string]string
+		expectedHost    string
+	}{
+		{
+			name: "exact match",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", Table: regions},
+			requestHeaders: map[string]string{
+				"X-Region": "us",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "iad-1",
+			},
+		},
+		{
+			name: "first entry of a key is used",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", Table: regions},
+			requestHeaders: map[string]string{
+				"X-Region": "eu",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "fra-1",
+			},
+		},
+		{
+			name: "exact match is case sensitive",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", Table: regions},
+			requestHeaders: map[string]string{
+				"X-Region":  "EU",
+				"X-Cluster": "unchanged",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "unchanged",
+			},
+		},
+		{
+			name: "case insensitive match",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", Table: regions, Match: types.MatchCaseInsensitive},
+			requestHeaders: map[string]string{
+				"X-Region": "EU",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "fra-1",
+			},
+		},
+		{
+			name: "regexp match",
+			rule: types.Rule{
+				From:   "X-Region",
+				Header: "X-Cluster",
+				Match:  types.MatchRegexp,
+				Table: []types.Mapping{
+					{Key: "^eu-(west|central)$", Value: "fra-$1"},
+					{Key: "^eu-", Value: "ams-1"},
+				},
+			},
+			requestHeaders: map[string]string{
+				"X-Region": "eu-west",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "fra-west",
+			},
+		},
+		{
+			name: "regexp match in order",
+			rule: types.Rule{
+				From:   "X-Region",
+				Header: "X-Cluster",
+				Match:  types.MatchRegexp,
+				Table: []types.Mapping{
+					{Key: "^eu-(west|central)$", Value: "fra-$1"},
+					{Key: "^eu-", Value: "ams-1"},
+				},
+			},
+			requestHeaders: map[string]string{
+				"X-Region": "eu-north",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "ams-1",
+			},
+		},
+		{
+			name: "miss leaves the header unchanged",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", Table: regions, OnFailure: types.Skip},
+			requestHeaders: map[string]string{
+				"X-Region":  "ap",
+				"X-Cluster": "unchanged",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "unchanged",
+			},
+		},
+		{
+			name: "miss writes the default value",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", Table: regions, OnFailure: types.Default, Value: "default"},
+			requestHeaders: map[string]string{
+				"X-Region": "ap",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "default",
+			},
+		},
+		{
+			name: "missing source writes the default value",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", Table: regions, OnFailure: types.Default, Value: "default"},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "default",
+			},
+		},
+		{
+			name: "map into the source header",
+			rule: types.Rule{From: "X-Region", Header: "X-Region", Table: regions},
+			requestHeaders: map[string]string{
+				"X-Region": "eu",
+			},
+			expectedHeaders: map[string]string{
+				"X-Region": "fra-1",
+			},
+		},
+		{
+			name: "map into Host",
+			rule: types.Rule{From: "X-Region", Header: "Host", Table: []types.Mapping{{Key: "eu", Value: "eu.example.com"}}},
+			requestHeaders: map[string]string{
+				"X-Region": "eu",
+			},
+			expectedHost: "eu.example.com",
+		},
+		{
+			name: "filtered value",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", Table: regions, Filters: []string{"upper"}},
+			requestHeaders: map[string]string{
+				"X-Region": "eu",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "FRA-1",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			for hName, hVal := range test.requestHeaders {
+				req.Header.Set(hName, hVal)
+			}
+
+			handler, err := mapping.New(test.rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			handler.Handle(nil, req)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equal(t, hVal, req.Header.Get(hName))
+			}
+
+			if test.expectedHost != "" {
+				assert.Equal(t, test.expectedHost, req.Host)
+			}
+		})
+	}
+}
+
+func TestMapResponse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		rule            types.Rule
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "response header",
+			rule: types.Rule{From: "resp:X-Cache", Header: "X-Cache-Status"},
+			expectedHeaders: map[string]string{
+				"X-Cache":        "MISS",
+				"X-Cache-Status": "0",
+			},
+		},
+		{
+			name: "request header",
+			rule: types.Rule{From: "req:X-Cache", Header: "X-Cache-Status"},
+			expectedHeaders: map[string]string{
+				"X-Cache":        "MISS",
+				"X-Cache-Status": "1",
+			},
+		},
+		{
+			name: "request header without namespace",
+			rule: types.Rule{From: "X-Cache", Header: "X-Cache-Status"},
+			expectedHeaders: map[string]string{
+				"X-Cache":        "MISS",
+				"X-Cache-Status": "1",
+			},
+		},
+		{
+			name: "original request header",
+			rule: types.Rule{From: "original:X-Cache", Header: "X-Cache-Status", OnFailure: types.Default, Value: "-1"},
+			expectedHeaders: map[string]string{
+				"X-Cache":        "MISS",
+				"X-Cache-Status": "-1",
+			},
+		},
+		{
+			name: "strip the response source",
+			rule: types.Rule{From: "resp:X-Cache", Header: "X-Cache-Status", RemoveSource: true},
+			expectedHeaders: map[string]string{
+				"X-Cache":        "",
+				"X-Cache-Status": "0",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			rule := test.rule
+			rule.Match = types.MatchCaseInsensitive
+			rule.Table = []types.Mapping{{Key: "hit", Value: "1"}, {Key: "miss", Value: "0"}}
+			rule.SetOnResponse = true
+
+			handler, err := mapping.New(rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			req = types.WithState(req, &types.State{})
+			types.GetState(req).SaveOriginal(req)
+			req.Header.Set("X-Cache", "HIT")
+
+			rw := httptest.NewRecorder()
+			rw.Header().Set("X-Cache", "MISS")
+
+			handler.Handle(rw, req)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equal(t, hVal, rw.Header().Get(hName))
+			}
+
+			assert.Equal(t, "HIT", req.Header.Get("X-Cache"))
+		})
+	}
+}
+
+func TestMapTableFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	csvFile := filepath.Join(dir, "consumers.csv")
+	require.NoError(t, os.WriteFile(csvFile, []byte("# API key,consumer\nk-123,acme\nk-456,globex\n"), 0o600))
+
+	jsonFile := filepath.Join(dir, "clusters.json")
+	require.NoError(t, os.WriteFile(jsonFile, []byte(`[{"Key": "^eu-(.+)$", "Value": "fra-$1"}]`), 0o600))
+
+	testCases := []struct {
+		name            string
+		rule            types.Rule
+		requestHeaders  map[string]string
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "CSV table",
+			rule: types.Rule{From: "X-Api-Key", Header: "X-Consumer-Id", TableFile: csvFile},
+			requestHeaders: map[string]string{
+				"X-Api-Key": "k-456",
+			},
+			expectedHeaders: map[string]string{
+				"X-Api-Key":     "k-456",
+				"X-Consumer-Id": "globex",
+			},
+		},
+		{
+			name: "strip the source",
+			rule: types.Rule{From: "X-Api-Key", Header: "X-Consumer-Id", TableFile: csvFile, RemoveSource: true},
+			requestHeaders: map[string]string{
+				"X-Api-Key": "k-123",
+			},
+			expectedHeaders: map[string]string{
+				"X-Api-Key":     "",
+				"X-Consumer-Id": "acme",
+			},
+		},
+		{
+			name: "strip the source on miss",
+			rule: types.Rule{
+				From:         "X-Api-Key",
+				Header:       "X-Consumer-Id",
+				TableFile:    csvFile,
+				RemoveSource: true,
+				OnFailure:    types.Default,
+				Value:        "anonymous",
+			},
+			requestHeaders: map[string]string{
+				"X-Api-Key":     "k-789",
+				"X-Consumer-Id": "spoofed",
+			},
+			expectedHeaders: map[string]string{
+				"X-Api-Key":     "",
+				"X-Consumer-Id": "anonymous",
+			},
+		},
+		{
+			name: "JSON regexp table",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", TableFile: jsonFile, Match: types.MatchRegexp},
+			requestHeaders: map[string]string{
+				"X-Region": "eu-west",
+			},
+			expectedHeaders: map[string]string{
+				"X-Cluster": "fra-west",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			for hName, hVal := range test.requestHeaders {
+				req.Header.Set(hName, hVal)
+			}
+
+			handler, err := mapping.New(test.rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			handler.Handle(nil, req)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equal(t, hVal, req.Header.Get(hName))
+			}
+		})
+	}
+}
+
+func TestMapTableFileReload(t *testing.T) {
+	t.Parallel()
+
+	tableFile := filepath.Join(t.TempDir(), "consumers.json")
+	require.NoError(t, os.WriteFile(tableFile, []byte(`{"k-123": "acme"}`), 0o600))
+
+	handler, err := mapping.New(types.Rule{From: "X-Api-Key", Header: "X-Consumer-Id", TableFile: tableFile})
+	require.NoError(t, err)
+
+	consumer := func() string {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+		require.NoError(t, err)
+
+		req.Header.Set("X-Api-Key", "k-123")
+		handler.Handle(nil, req)
+
+		return req.Header.Get("X-Consumer-Id")
+	}
+
+	assert.Equal(t, "acme", consumer())
+
+	// Files are checked for changes once per second.
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.WriteFile(tableFile, []byte(`{"k-123": "globex"}`), 0o600))
+	require.NoError(t, os.Chtimes(tableFile, modTime, modTime))
+	time.Sleep(1100 * time.Millisecond)
+
+	assert.Equal(t, "globex", consumer())
+
+	// A table that cannot be parsed is ignored, the last one being kept.
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.WriteFile(tableFile, []byte(`{"k-123": "initech"`), 0o600))
+	require.NoError(t, os.Chtimes(tableFile, modTime, modTime))
+	time.Sleep(1100 * time.Millisecond)
+
+	assert.Equal(t, "globex", consumer())
+
+	// So is an empty table, while the file is being rewritten.
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.WriteFile(tableFile, nil, 0o600))
+	require.NoError(t, os.Chtimes(tableFile, modTime, modTime))
+	time.Sleep(1100 * time.Millisecond)
+
+	assert.Equal(t, "globex", consumer())
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	table := []types.Mapping{{Key: "eu", Value: "fra-1"}}
+
+	tableFile := filepath.Join(t.TempDir(), "regions.csv")
+	require.NoError(t, os.WriteFile(tableFile, []byte("eu,fra-1\n"), 0o600))
+
+	invalidFile := filepath.Join(t.TempDir(), "regions.csv")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("eu\n"), 0o600))
+
+	emptyFile := filepath.Join(t.TempDir(), "regions.csv")
+	require.NoError(t, os.WriteFile(emptyFile, []byte("# region,cluster\n"), 0o600))
+
+	testCases := []struct {
+		name       string
+		rule       types.Rule
+		wantErr    bool
+		wantNewErr bool
+	}{
+		{
+			name:    "missing from",
+			rule:    types.Rule{Header: "X-Cluster", Table: table},
+			wantErr: true,
+		},
+		{
+			name:    "missing header",
+			rule:    types.Rule{From: "X-Region", Table: table},
+			wantErr: true,
+		},
+		{
+			name:    "missing table",
+			rule:    types.Rule{From: "X-Region", Header: "X-Cluster"},
+			wantErr: true,
+		},
+		{
+			name:    "response header on request",
+			rule:    types.Rule{From: "resp:X-Region", Header: "X-Cluster", Table: table},
+			wantErr: true,
+		},
+		{
+			name:    "invalid match",
+			rule:    types.Rule{From: "X-Region", Header: "X-Cluster", Table: table, Match: "Prefix"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid failure action",
+			rule:    types.Rule{From: "X-Region", Header: "X-Cluster", Table: table, OnFailure: types.Reject},
+			wantErr: true,
+		},
+		{
+			name:       "invalid regexp",
+			rule:       types.Rule{From: "X-Region", Header: "X-Cluster", Table: []types.Mapping{{Key: "(eu"}}, Match: types.MatchRegexp},
+			wantNewErr: true,
+		},
+		{
+			name:    "table and table file",
+			rule:    types.Rule{From: "X-Region", Header: "X-Cluster", Table: table, TableFile: tableFile},
+			wantErr: true,
+		},
+		{
+			name:       "missing table file",
+			rule:       types.Rule{From: "X-Region", Header: "X-Cluster", TableFile: filepath.Join(t.TempDir(), "missing.csv")},
+			wantNewErr: true,
+		},
+		{
+			name:       "invalid table file",
+			rule:       types.Rule{From: "X-Region", Header: "X-Cluster", TableFile: invalidFile},
+			wantNewErr: true,
+		},
+		{
+			name:       "empty table file",
+			rule:       types.Rule{From: "X-Region", Header: "X-Cluster", TableFile: emptyFile},
+			wantNewErr: true,
+		},
+		{
+			name: "valid rule",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", Table: table, OnFailure: types.Default},
+		},
+		{
+			name: "valid table file rule",
+			rule: types.Rule{From: "X-Region", Header: "X-Cluster", TableFile: tableFile},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := mapping.New(test.rule)
+			if test.wantNewErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package messagesignature
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/httpsig"
+	"github.com/tomMoulard/htransformation/pkg/utils/key"
+)
+
+const defaultLabel = "sig1"
+
+var defaultComponents = []string{"@method", "@authority", "@path", "@query"}
+
+type MessageSignature struct {
+	rule       *types.Rule
+	key        *key.Key
+	label      string
+	components []string
+	expires    time.Duration
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	signingKey, err := key.New(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &MessageSignature{rule: &rule, key: signingKey, label: rule.Label, components: defaultComponents}
+
+	if handler.label == "" {
+		handler.label = defaultLabel
+	}
+
+	if len(rule.Values) > 0 {
+		// Header fields are identified by their lower case name.
+		handler.components = make([]string, 0, len(rule.Values))
+		for _, component := range rule.Values {
+			handler.components = append(handler.components, strings.ToLower(component))
+		}
+	}
+
+	if rule.Expires != "" {
+		handler.expires, err = time.ParseDuration(rule.Expires)
+		if err != nil || handler.expires <= 0 {
+			return nil, fmt.Errorf("%w: Expires %q", types.ErrInvalidDuration, rule.Expires)
+		}
+	}
+
+	return handler, nil
+}
+
+func (m *MessageSignature) Validate() error {
+	if len(m.rule.Filters) > 0 {
+		return fmt.Errorf("%w: not supported by MessageSignature rules", types.ErrInvalidFilter)
+	}
+
+	if len(m.key.Bytes()) == 0 {
+		return fmt.Errorf("%w: Key", types.ErrMissingRequiredFields)
+	}
+
+	if m.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	if err := m.validateAlgorithm(); err != nil {
+		return err
+	}
+
+	switch m.rule.OnFailure {
+	case "", types.Skip, types.Reject:
+	default:
+		return types.ErrInvalidFailureAction
+	}
+
+	for _, component := range m.components {
+		if !httpsig.ValidComponent(component) {
+			return fmt.Errorf("%w: %q", types.ErrUnknownField, component)
+		}
+	}
+
+	return nil
+}
+
+func (m *MessageSignature) validateAlgorithm() error {
+	switch m.rule.Algorithm {
+	case "", types.HMACSHA256:
+		return nil
+	case types.Ed25519:
+		_, err := m.privateKey()
+
+		return err
+	default:
+		return fmt.Errorf("%w: %q", types.ErrInvalidAlgorithm, m.rule.Algorithm)
+	}
+}
+
+func (m *MessageSignature) Handle(_ http.ResponseWriter, req *http.Request) {
+	params := httpsig.Params{Created: time.Now().Unix(), KeyID: m.rule.KeyID}
+	if m.expires != 0 {
+		params.Expires = params.Created + int64(m.expires/time.Second)
+	}
+
+	input := httpsig.Input(m.components, params)
+
+	base, err := httpsig.Base(req, m.components, input)
+	if err != nil {
+		if m.rule.OnFailure == types.Reject {
+			types.RejectRequest(req, m.rule.RejectStatus, http.StatusBadRequest)
+		}
+
+		return
+	}
+
+	signature, err := m.sign([]byte(base))
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Signature-Input", m.label+"="+input)
+	req.Header.Set("Signature", m.label+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+}
+
+func (m *MessageSignature) sign(base []byte) ([]byte, error) {
+	if m.rule.Algorithm == types.Ed25519 {
+		privateKey, err := m.privateKey()
+		if err != nil {
+			return nil, err
+		}
+
+		return ed25519.Sign(privateKey, base), nil
+	}
+
+	mac := hmac.New(sha256.New, m.key.Bytes())
+	mac.Write(base)
+
+	return mac.Sum(nil), nil
+}
+
+// privateKey parses the current key, which is read again when its file changes.
+func (m *MessageSignature) privateKey() (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(m.key.Bytes())
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data", types.ErrInvalidKey)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidKey, err)
+	}
+
+	privateKey, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an Ed25519 key", types.ErrInvalidKey)
+	}
+
+	return privateKey, nil
+}
@@ -0,0 +1,261 @@
+package messagesignature_test
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/messagesignature"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/httpsig"
+)
+
+var createdPattern = regexp.MustCompile(`;created=(\d+)`)
+
+// ed25519Key returns a new Ed25519 key and its PKCS #8 PEM encoding.
+func ed25519Key(t *testing.T) (ed25519.PublicKey, string) {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	return publicKey, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func newRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "https://example.com/foo?param=Value", nil)
+	require.NoError(t, err)
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return req
+}
+
+func TestMessageSignatureHandler(t *testing.T) {
+	t.Parallel()
+
+	publicKey, privateKeyPEM := ed25519Key(t)
+
+	testCases := []struct {
+		name       string
+		rule       types.Rule
+		components []string
+		// expectedInput is the expected Signature-Input, %t standing for the created time.
+		expectedInput string
+		verify        func(t *testing.T, base, signature []byte)
+	}{
+		{
+			name:          "HMAC-SHA256 over default components",
+			rule:          types.Rule{Key: "shared-secret"},
+			components:    []string{"@method", "@authority", "@path", "@query"},
+			expectedInput: `sig1=("@method" "@authority" "@path" "@query");created=%t`,
+			verify: func(t *testing.T, base, signature []byte) {
+				t.Helper()
+
+				mac := hmac.New(sha256.New, []byte("shared-secret"))
+				mac.Write(base)
+				assert.Equal(t, mac.Sum(nil), signature)
+			},
+		},
+		{
+			name: "Ed25519 with parameters",
+			rule: types.Rule{
+				Key:       privateKeyPEM,
+				Algorithm: types.Ed25519,
+				Label:     "partner",
+				KeyID:     "gateway-2024",
+				Expires:   "5m",
+				Values:    []string{"@method", "@target-uri", "Content-Type"},
+			},
+			components:    []string{"@method", "@target-uri", "content-type"},
+			expectedInput: `partner=("@method" "@target-uri" "content-type");created=%t;expires=%e;keyid="gateway-2024"`,
+			verify: func(t *testing.T, base, signature []byte) {
+				t.Helper()
+
+				assert.Equal(t, true, ed25519.Verify(publicKey, base, signature))
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := newRequest(t)
+
+			handler, err := messagesignature.New(test.rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			before := time.Now().Unix()
+			handler.Handle(nil, req)
+
+			signatureInput := req.Header.Get("Signature-Input")
+
+			match := createdPattern.FindStringSubmatch(signatureInput)
+			if match == nil {
+				t.Fatalf("no created parameter in %q", signatureInput)
+			}
+
+			created, err := strconv.ParseInt(match[1], 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, true, created >= before && created <= time.Now().Unix())
+
+			expectedInput := strings.NewReplacer(
+				"%t", match[1],
+				"%e", strconv.FormatInt(created+300, 10),
+			).Replace(test.expectedInput)
+			assert.Equal(t, expectedInput, signatureInput)
+
+			label, input, _ := strings.Cut(signatureInput, "=")
+
+			signature, ok := strings.CutPrefix(req.Header.Get("Signature"), label+"=:")
+			if !ok {
+				t.Fatalf("unexpected Signature %q", req.Header.Get("Signature"))
+			}
+
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(signature, ":"))
+			require.NoError(t, err)
+
+			base, err := httpsig.Base(newRequest(t), test.components, input)
+			require.NoError(t, err)
+
+			test.verify(t, []byte(base), decoded)
+		})
+	}
+}
+
+func TestMissingComponent(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		rule                 types.Rule
+		expectedRejectStatus int
+	}{
+		{
+			name: "skipped",
+			rule: types.Rule{Key: "secret", Values: []string{"@method", "x-missing"}},
+		},
+		{
+			name:                 "rejected",
+			rule:                 types.Rule{Key: "secret", Values: []string{"x-missing"}, OnFailure: types.Reject},
+			expectedRejectStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			state := &types.State{}
+			req := types.WithState(newRequest(t), state)
+
+			handler, err := messagesignature.New(test.rule)
+			require.NoError(t, err)
+
+			handler.Handle(nil, req)
+
+			assert.Equal(t, "", req.Header.Get("Signature"))
+			assert.Equal(t, test.expectedRejectStatus, state.RejectStatus)
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	_, privateKeyPEM := ed25519Key(t)
+
+	testCases := []struct {
+		name       string
+		rule       types.Rule
+		wantNewErr bool
+		wantErr    bool
+	}{
+		{
+			name:    "missing key",
+			rule:    types.Rule{},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{Key: "secret", SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported algorithm",
+			rule:    types.Rule{Key: "secret", Algorithm: types.HMACSHA512},
+			wantErr: true,
+		},
+		{
+			name:    "invalid Ed25519 key",
+			rule:    types.Rule{Key: "secret", Algorithm: types.Ed25519},
+			wantErr: true,
+		},
+		{
+			name:    "unknown derived component",
+			rule:    types.Rule{Key: "secret", Values: []string{"@status"}},
+			wantErr: true,
+		},
+		{
+			name:       "invalid expiration",
+			rule:       types.Rule{Key: "secret", Expires: "soon"},
+			wantNewErr: true,
+		},
+		{
+			name:    "unsupported failure action",
+			rule:    types.Rule{Key: "secret", OnFailure: types.Default},
+			wantErr: true,
+		},
+		{
+			name:    "filters",
+			rule:    types.Rule{Key: "secret", Filters: []string{"lower"}},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Key: privateKeyPEM, Algorithm: types.Ed25519, Expires: "1m", Values: []string{"@path", "Date"}},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := messagesignature.New(test.rule)
+			if test.wantNewErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
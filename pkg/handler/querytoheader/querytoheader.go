@@ -0,0 +1,49 @@
+package querytoheader
+
+import (
+	"net/http"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+	"github.com/tomMoulard/htransformation/pkg/utils/query"
+)
+
+type QueryToHeader struct {
+	rule    *types.Rule
+	filters *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	return &QueryToHeader{rule: &rule, filters: filter.New(rule.Filters)}, nil
+}
+
+func (q *QueryToHeader) Validate() error {
+	if err := q.filters.Validate(); err != nil {
+		return err
+	}
+
+	if q.rule.Param == "" || q.rule.Header == "" {
+		return types.ErrMissingRequiredFields
+	}
+
+	if q.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	return nil
+}
+
+func (q *QueryToHeader) Handle(_ http.ResponseWriter, req *http.Request) {
+	values, ok := req.URL.Query()[q.rule.Param]
+	if !ok {
+		return
+	}
+
+	// Only the first value is used when the parameter is repeated.
+	header.Set(req, q.rule.Header, q.filters.Apply(values[0]))
+
+	if q.rule.RemoveSource {
+		req.URL.RawQuery = query.Remove(req.URL.RawQuery, q.rule.Param)
+	}
+}
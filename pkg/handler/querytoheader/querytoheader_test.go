@@ -0,0 +1,141 @@
+package querytoheader_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/querytoheader"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestQueryToHeaderHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		rule             types.Rule
+		url              string
+		expectedHeaders  map[string]string
+		expectedRawQuery string
+	}{
+		{
+			name: "copy parameter",
+			rule: types.Rule{
+				Header: "X-Tenant",
+				Param:  "tenant",
+			},
+			url: "http://example.com/foo?tenant=acme&page=2",
+			expectedHeaders: map[string]string{
+				"X-Tenant": "acme",
+			},
+			expectedRawQuery: "tenant=acme&page=2",
+		},
+		{
+			name: "move parameter",
+			rule: types.Rule{
+				Header:       "X-Tenant",
+				Param:        "tenant",
+				RemoveSource: true,
+			},
+			url: "http://example.com/foo?tenant=acme&page=2",
+			expectedHeaders: map[string]string{
+				"X-Tenant": "acme",
+			},
+			expectedRawQuery: "page=2",
+		},
+		{
+			name: "first of repeated parameters",
+			rule: types.Rule{
+				Header: "X-Tenant",
+				Param:  "tenant",
+			},
+			url: "http://example.com/foo?tenant=acme&tenant=other",
+			expectedHeaders: map[string]string{
+				"X-Tenant": "acme",
+			},
+			expectedRawQuery: "tenant=acme&tenant=other",
+		},
+		{
+			name: "missing parameter",
+			rule: types.Rule{
+				Header:       "X-Tenant",
+				Param:        "tenant",
+				RemoveSource: true,
+			},
+			url: "http://example.com/foo?page=2",
+			expectedHeaders: map[string]string{
+				"X-Tenant": "",
+			},
+			expectedRawQuery: "page=2",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, test.url, nil)
+			require.NoError(t, err)
+
+			handler, err := querytoheader.New(test.rule)
+			require.NoError(t, err)
+
+			handler.Handle(nil, req)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equal(t, hVal, req.Header.Get(hName))
+			}
+
+			assert.Equal(t, test.expectedRawQuery, req.URL.RawQuery)
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "missing param",
+			rule:    types.Rule{Header: "X-Tenant"},
+			wantErr: true,
+		},
+		{
+			name:    "missing header",
+			rule:    types.Rule{Param: "tenant"},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{Header: "X-Tenant", Param: "tenant", SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Header: "X-Tenant", Param: "tenant"},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := querytoheader.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
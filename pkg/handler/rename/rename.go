@@ -5,12 +5,14 @@ import (
 	"net/http"
 	"regexp"
 
+	"github.com/tomMoulard/htransformation/pkg/filter"
 	"github.com/tomMoulard/htransformation/pkg/types"
 	"github.com/tomMoulard/htransformation/pkg/utils/header"
 )
 
 type Rename struct {
-	rule *types.Rule
+	rule    *types.Rule
+	filters *filter.Chain
 }
 
 func New(rule types.Rule) (types.Handler, error) {
@@ -21,10 +23,14 @@ func New(rule types.Rule) (types.Handler, error) {
 
 	rule.Regexp = re
 
-	return &Rename{rule: &rule}, nil
+	return &Rename{rule: &rule, filters: filter.New(rule.Filters)}, nil
 }
 
 func (r *Rename) Validate() error {
+	if err := r.filters.Validate(); err != nil {
+		return err
+	}
+
 	if r.rule.Value == "" {
 		return types.ErrMissingRequiredFields
 	}
@@ -48,6 +54,7 @@ func (r *Rename) Handle(rw http.ResponseWriter, req *http.Request) {
 		}
 
 		for _, val := range headerValues {
+			val = r.filters.Apply(val)
 			if r.rule.SetOnResponse {
 				rw.Header().Set(r.rule.Value, val)
 			} else {
@@ -0,0 +1,143 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/id"
+)
+
+const (
+	defaultHeader    = "X-Request-Id"
+	defaultMaxLength = 128
+)
+
+type RequestID struct {
+	rule      *types.Rule
+	header    string
+	format    types.IDFormat
+	maxLength int
+	// stateKey is where the request ID is stored for the response.
+	stateKey string
+	filters  *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	header := rule.Header
+	if header == "" {
+		header = defaultHeader
+	}
+
+	format := rule.Format
+	if format == "" {
+		format = types.UUIDv4
+	}
+
+	maxLength := rule.MaxLength
+	if maxLength == 0 {
+		maxLength = defaultMaxLength
+	}
+
+	return &RequestID{
+		rule:      &rule,
+		header:    header,
+		format:    format,
+		maxLength: maxLength,
+		stateKey:  "RequestID " + http.CanonicalHeaderKey(header),
+		filters:   filter.New(rule.Filters),
+	}, nil
+}
+
+func (r *RequestID) Validate() error {
+	if err := r.filters.Validate(); err != nil {
+		return err
+	}
+
+	if r.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	switch r.format {
+	case types.UUIDv4, types.UUIDv7, types.ULID:
+	default:
+		return fmt.Errorf("%w: %q", types.ErrInvalidFormat, r.format)
+	}
+
+	switch r.rule.OnFailure {
+	case "", types.Skip, types.Default, types.Reject:
+		return nil
+	default:
+		return types.ErrInvalidFailureAction
+	}
+}
+
+func (r *RequestID) Handle(_ http.ResponseWriter, req *http.Request) {
+	requestID := req.Header.Get(r.header)
+
+	if requestID != "" && !r.valid(requestID) {
+		switch r.rule.OnFailure {
+		case types.Reject:
+			types.RejectRequest(req, r.rule.RejectStatus, http.StatusBadRequest)
+
+			return
+		case types.Default:
+			requestID = ""
+		}
+	}
+
+	if requestID == "" {
+		var err error
+
+		requestID, err = r.generate()
+		if err != nil {
+			return
+		}
+
+		// Filters apply to generated IDs only, incoming IDs being forwarded as is.
+		requestID = r.filters.Apply(requestID)
+		req.Header.Set(r.header, requestID)
+	}
+
+	types.GetState(req).SetValue(r.stateKey, requestID)
+}
+
+// HandleResponse echoes the request ID on the response.
+func (r *RequestID) HandleResponse(rw http.ResponseWriter, req *http.Request) {
+	if requestID, ok := types.GetState(req).Value(r.stateKey); ok {
+		rw.Header().Set(r.header, requestID)
+	}
+}
+
+func (r *RequestID) generate() (string, error) {
+	switch r.format {
+	case types.UUIDv7:
+		return id.UUIDv7(time.Now(), rand.Reader)
+	case types.ULID:
+		return id.ULID(time.Now(), rand.Reader)
+	default:
+		return id.UUIDv4(rand.Reader)
+	}
+}
+
+// valid reports whether requestID is short enough and only made of letters,
+// digits and the characters -_.:+/= found in common ID formats.
+func (r *RequestID) valid(requestID string) bool {
+	if len(requestID) > r.maxLength {
+		return false
+	}
+
+	for _, char := range requestID {
+		switch {
+		case 'a' <= char && char <= 'z', 'A' <= char && char <= 'Z', '0' <= char && char <= '9':
+		case char == '-', char == '_', char == '.', char == ':', char == '+', char == '/', char == '=':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
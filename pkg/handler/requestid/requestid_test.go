@@ -0,0 +1,202 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/requestid"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+var (
+	uuidv4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	uuidv7 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulid   = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+func TestRequestIDHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		rule                 types.Rule
+		requestID            string
+		expectedID           string
+		expectedFormat       *regexp.Regexp
+		expectedRejectStatus int
+	}{
+		{
+			name:           "generate UUIDv4",
+			rule:           types.Rule{},
+			expectedFormat: uuidv4,
+		},
+		{
+			name:           "generate UUIDv7",
+			rule:           types.Rule{Format: types.UUIDv7},
+			expectedFormat: uuidv7,
+		},
+		{
+			name:           "generate ULID",
+			rule:           types.Rule{Format: types.ULID},
+			expectedFormat: ulid,
+		},
+		{
+			name:       "keep incoming ID",
+			rule:       types.Rule{OnFailure: types.Reject},
+			requestID:  "7f1c2a/trace:01=",
+			expectedID: "7f1c2a/trace:01=",
+		},
+		{
+			name:       "keep malformed ID",
+			rule:       types.Rule{},
+			requestID:  "<script>",
+			expectedID: "<script>",
+		},
+		{
+			name:           "replace malformed ID",
+			rule:           types.Rule{OnFailure: types.Default},
+			requestID:      "<script>",
+			expectedFormat: uuidv4,
+		},
+		{
+			name:                 "reject malformed ID",
+			rule:                 types.Rule{OnFailure: types.Reject},
+			requestID:            "a b",
+			expectedRejectStatus: http.StatusBadRequest,
+		},
+		{
+			name:                 "reject long ID",
+			rule:                 types.Rule{OnFailure: types.Reject, MaxLength: 8, RejectStatus: http.StatusForbidden},
+			requestID:            "123456789",
+			expectedRejectStatus: http.StatusForbidden,
+		},
+		{
+			name:                 "reject ID longer than default",
+			rule:                 types.Rule{OnFailure: types.Reject},
+			requestID:            strings.Repeat("a", 129),
+			expectedRejectStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "filtered generated ID",
+			rule:           types.Rule{Format: types.ULID, Filters: []string{"lower", "substring:0:10"}},
+			expectedFormat: regexp.MustCompile(`^[0-7][0-9a-hjkmnp-tv-z]{9}$`),
+		},
+		{
+			name:       "incoming ID is not filtered",
+			rule:       types.Rule{Filters: []string{"upper"}},
+			requestID:  "abc",
+			expectedID: "abc",
+		},
+		{
+			name:       "custom header",
+			rule:       types.Rule{Header: "X-Correlation-Id"},
+			requestID:  "abc",
+			expectedID: "abc",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			header := test.rule.Header
+			if header == "" {
+				header = "X-Request-Id"
+			}
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			if test.requestID != "" {
+				req.Header.Set(header, test.requestID)
+			}
+
+			state := &types.State{}
+			req = types.WithState(req, state)
+
+			handler, err := requestid.New(test.rule)
+			require.NoError(t, err)
+
+			handler.Handle(nil, req)
+
+			assert.Equal(t, test.expectedRejectStatus, state.RejectStatus)
+
+			if test.expectedRejectStatus != 0 {
+				return
+			}
+
+			requestID := req.Header.Get(header)
+			if test.expectedFormat != nil {
+				assert.Equalf(t, true, test.expectedFormat.MatchString(requestID), "request ID %q", requestID)
+			} else {
+				assert.Equal(t, test.expectedID, requestID)
+			}
+
+			responder, ok := handler.(types.ResponseHandler)
+			if !ok {
+				t.Fatalf("%T does not handle responses", handler)
+			}
+
+			recorder := httptest.NewRecorder()
+			responder.HandleResponse(recorder, req)
+			assert.Equal(t, requestID, recorder.Header().Get(header))
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "on response",
+			rule:    types.Rule{SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			rule:    types.Rule{Format: "UUIDv1"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown failure action",
+			rule:    types.Rule{OnFailure: "Drop"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown filter",
+			rule:    types.Rule{Filters: []string{"bogus"}},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Format: types.UUIDv7, OnFailure: types.Default, MaxLength: 64},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := requestid.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -6,13 +6,17 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/tomMoulard/htransformation/pkg/filter"
 	"github.com/tomMoulard/htransformation/pkg/types"
 	"github.com/tomMoulard/htransformation/pkg/utils/header"
+	"github.com/tomMoulard/htransformation/pkg/value"
 )
 
 type Rewrite struct {
 	rule            *types.Rule
 	ruleValueRegexp *regexp.Regexp
+	valueReplace    value.Value
+	filters         *filter.Chain
 }
 
 func New(rule types.Rule) (types.Handler, error) {
@@ -28,28 +32,49 @@ func New(rule types.Rule) (types.Handler, error) {
 		return nil, fmt.Errorf("%w: %s: %q", types.ErrInvalidRegexp, rule.Name, rule.Value)
 	}
 
+	// ValueReplace is only a template when the rule opts in, so that existing
+	// rules keep writing it as is.
+	valueReplace := value.NewLiteral(rule.ValueReplace)
+
+	if rule.Interpolate {
+		valueReplace, err = value.New(rule.ValueReplace, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &Rewrite{
 		rule:            &rule,
 		ruleValueRegexp: reg,
+		valueReplace:    valueReplace,
+		filters:         filter.New(rule.Filters),
 	}, nil
 }
 
 func (r *Rewrite) Validate() error {
+	if err := r.filters.Validate(); err != nil {
+		return err
+	}
+
 	if r.rule.ValueReplace == "" {
 		return types.ErrMissingRequiredFields
 	}
 
+	if !r.rule.SetOnResponse && value.ReferencesResponse(r.valueReplace) {
+		return types.ErrResponseOnly
+	}
+
 	return nil
 }
 
-func (r *Rewrite) replaceHeaderValue(headerValue string) string {
+func (r *Rewrite) replaceHeaderValue(headerValue, valueReplace string) string {
 	return r.ruleValueRegexp.ReplaceAllStringFunc(headerValue, func(match string) string {
 		captures := r.ruleValueRegexp.FindStringSubmatch(match)
 		if len(captures) == 0 || captures[0] == "" {
 			return match
 		}
 
-		replaced := r.rule.ValueReplace
+		replaced := valueReplace
 
 		for j, capture := range captures[1:] {
 			replaced = strings.ReplaceAll(replaced, fmt.Sprintf("$%d", j+1), capture)
@@ -65,6 +90,11 @@ func (r *Rewrite) Handle(rw http.ResponseWriter, req *http.Request) {
 		headers = rw.Header()
 	}
 
+	valueReplace, ok := r.valueReplace.Resolve(rw, req)
+	if !ok {
+		return
+	}
+
 	originalHost := req.Header.Get("Host") // Eventually X-Forwarded-Host
 	req.Header.Set("Host", req.Host)
 
@@ -80,7 +110,7 @@ func (r *Rewrite) Handle(rw http.ResponseWriter, req *http.Request) {
 		}
 
 		for _, headerValue := range headerValues {
-			replacedValue := r.replaceHeaderValue(headerValue)
+			replacedValue := r.filters.Apply(r.replaceHeaderValue(headerValue, valueReplace))
 			if r.rule.SetOnResponse {
 				rw.Header().Add(headerName, replacedValue)
 			} else {
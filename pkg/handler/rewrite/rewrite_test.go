@@ -35,6 +35,24 @@ func TestRewriteHandler(t *testing.T) {
 			},
 			expectedHost: "example.com",
 		},
+		{
+			name: "templated replacement",
+			rule: types.Rule{
+				Header:       "F(.*)",
+				Value:        `X-(\d*)-(.*)`,
+				ValueReplace: `{{ .Method }}-$2-{{ header "Bar" }}`,
+				Interpolate:  true,
+			},
+			requestHeaders: map[string]string{
+				"Bar": "Baz",
+				"Foo": "X-12-Test",
+			},
+			expectedHeaders: map[string]string{
+				"Bar": "Baz",
+				"Foo": "GET-Test-Baz",
+			},
+			expectedHost: "example.com",
+		},
 		{
 			name: "one transformation with 2 headers",
 			rule: types.Rule{
@@ -191,6 +209,17 @@ func TestValidation(t *testing.T) {
 			},
 			wantValidateErr: true,
 		},
+		{
+			name: "response header in template on request",
+			rule: types.Rule{
+				Header:       "X-Version",
+				Value:        "(.+)",
+				ValueReplace: `{{ header "resp:ETag" }}`,
+				Type:         types.RewriteValueRule,
+				Interpolate:  true,
+			},
+			wantValidateErr: true,
+		},
 		{
 			name: "invalid Header regexp",
 			rule: types.Rule{
@@ -3,32 +3,63 @@ package set
 import (
 	"net/http"
 
+	"github.com/tomMoulard/htransformation/pkg/filter"
 	"github.com/tomMoulard/htransformation/pkg/types"
 	"github.com/tomMoulard/htransformation/pkg/utils/header"
+	"github.com/tomMoulard/htransformation/pkg/value"
 )
 
 type Set struct {
-	rule *types.Rule
+	rule    *types.Rule
+	value   value.Value
+	filters *filter.Chain
 }
 
 func New(rule types.Rule) (types.Handler, error) {
-	return &Set{rule: &rule}, nil
+	// Value is only compiled when the rule opts in, so that existing Set rules keep writing it as is.
+	ruleValue := value.NewLiteral(rule.Value)
+
+	if rule.Interpolate {
+		var err error
+
+		ruleValue, err = value.New(rule.Value, rule.HeaderPrefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Set{rule: &rule, value: ruleValue, filters: filter.New(rule.Filters)}, nil
 }
 
 func (s *Set) Validate() error {
+	if err := s.filters.Validate(); err != nil {
+		return err
+	}
+
 	if s.rule.Header == "" {
 		return types.ErrMissingRequiredFields
 	}
 
+	if !s.rule.SetOnResponse && value.ReferencesResponse(s.value) {
+		return types.ErrResponseOnly
+	}
+
 	return nil
 }
 
 func (s *Set) Handle(rw http.ResponseWriter, req *http.Request) {
+	value, ok := s.value.Resolve(rw, req)
+	if !ok {
+		return
+	}
+
+	value = s.filters.Apply(value)
+
 	if s.rule.SetOnResponse {
-		rw.Header().Set(s.rule.Header, s.rule.Value)
+		rw.Header().Set(s.rule.Header, value)
 
 		return
 	}
 
-	header.Set(req, s.rule.Header, s.rule.Value)
+	header.Set(req, s.rule.Header, value)
 }
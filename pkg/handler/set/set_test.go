@@ -15,12 +15,13 @@ func TestSetHandler(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name           string
-		rule           types.Rule
-		requestHeaders map[string]string
-		wantOnRequest  map[string]string
-		wantOnResponse map[string]string
-		expectedHost   string
+		name            string
+		rule            types.Rule
+		requestHeaders  map[string]string
+		responseHeaders map[string]string
+		wantOnRequest   map[string]string
+		wantOnResponse  map[string]string
+		expectedHost    string
 	}{
 		{
 			name: "Set one simple",
@@ -37,6 +38,69 @@ func TestSetHandler(t *testing.T) {
 			},
 			expectedHost: "example.com",
 		},
+		{
+			name: "Set templated value",
+			rule: types.Rule{
+				Header:      "X-Test",
+				Value:       `{{ .Method }} {{ .Host }}{{ .Path }} from {{ header "Foo" }}`,
+				Interpolate: true,
+			},
+			requestHeaders: map[string]string{
+				"Foo": "Bar",
+			},
+			wantOnRequest: map[string]string{
+				"Foo":    "Bar",
+				"X-Test": "GET example.com/foo from Bar",
+			},
+			expectedHost: "example.com",
+		},
+		{
+			name: "Set literal value without Interpolate",
+			rule: types.Rule{
+				Header:       "X-Test",
+				Value:        "^Foo {{ .Method }} ${env:HOME}",
+				HeaderPrefix: "^",
+			},
+			requestHeaders: map[string]string{
+				"Foo": "Bar",
+			},
+			wantOnRequest: map[string]string{
+				"X-Test": "^Foo {{ .Method }} ${env:HOME}",
+			},
+			expectedHost: "example.com",
+		},
+		{
+			name: "Set template failing to render",
+			rule: types.Rule{
+				Header:      "X-Test",
+				Value:       `{{ .TLS.Version }}`,
+				Interpolate: true,
+			},
+			requestHeaders: map[string]string{
+				"X-Test": "Bar",
+			},
+			wantOnRequest: map[string]string{
+				"X-Test": "Bar",
+			},
+			expectedHost: "example.com",
+		},
+		{
+			name: "Set filtered value",
+			rule: types.Rule{
+				Header:       "X-Test",
+				Value:        "^Foo",
+				Interpolate:  true,
+				HeaderPrefix: "^",
+				Filters:      []string{"trim", "lower", "base64"},
+			},
+			requestHeaders: map[string]string{
+				"Foo": " BAR ",
+			},
+			wantOnRequest: map[string]string{
+				"X-Test": "YmFy",
+			},
+			expectedHost: "example.com",
+		},
 		{
 			name: "Set already existing simple",
 			rule: types.Rule{
@@ -71,6 +135,43 @@ func TestSetHandler(t *testing.T) {
 			},
 			expectedHost: "example.com",
 		},
+		{
+			name: "Set from response header",
+			rule: types.Rule{
+				Header:        "X-Version",
+				Value:         "^resp:ETag",
+				Interpolate:   true,
+				HeaderPrefix:  "^",
+				SetOnResponse: true,
+			},
+			requestHeaders: map[string]string{
+				"ETag": "request",
+			},
+			responseHeaders: map[string]string{
+				"ETag": `"v1"`,
+			},
+			wantOnResponse: map[string]string{
+				"X-Version": `"v1"`,
+			},
+			expectedHost: "example.com",
+		},
+		{
+			name: "Echo request header on response",
+			rule: types.Rule{
+				Header:        "X-Request-Id",
+				Value:         "^req:X-Request-Id",
+				Interpolate:   true,
+				HeaderPrefix:  "^",
+				SetOnResponse: true,
+			},
+			requestHeaders: map[string]string{
+				"X-Request-Id": "abc",
+			},
+			wantOnResponse: map[string]string{
+				"X-Request-Id": "abc",
+			},
+			expectedHost: "example.com",
+		},
 		{
 			name: "Set Host header",
 			rule: types.Rule{
@@ -96,6 +197,10 @@ func TestSetHandler(t *testing.T) {
 			require.NoError(t, err)
 
 			rw := httptest.NewRecorder()
+			for hName, hVal := range test.responseHeaders {
+				rw.Header().Set(hName, hVal)
+			}
+
 			setHandler.Handle(rw, req)
 
 			for hName, hVal := range test.wantOnRequest {
@@ -131,11 +236,42 @@ func TestValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "response header on request",
+			rule: types.Rule{
+				Header:       "X-Version",
+				Value:        "^resp:ETag",
+				Interpolate:  true,
+				HeaderPrefix: "^",
+				Type:         types.Set,
+			},
+			wantErr: true,
+		},
+		{
+			name: "response header in template on request",
+			rule: types.Rule{
+				Header:      "X-Version",
+				Value:       `{{ header "resp:ETag" }}`,
+				Interpolate: true,
+				Type:        types.Set,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown filter",
+			rule: types.Rule{
+				Header:  "not-empty",
+				Type:    types.Set,
+				Filters: []string{"lower", "reverse"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid rule",
 			rule: types.Rule{
-				Header: "not-empty",
-				Type:   types.Set,
+				Header:  "not-empty",
+				Type:    types.Set,
+				Filters: []string{"lower"},
 			},
 			wantErr: false,
 		},
@@ -0,0 +1,141 @@
+package sign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/key"
+)
+
+const (
+	defaultHeader          = "X-Signature"
+	defaultTimestampHeader = "X-Signature-Timestamp"
+	headerComponent        = "header:"
+)
+
+var defaultComponents = []string{"method", "path", "timestamp"}
+
+// components maps the names of the canonical string components to their value.
+var components = map[string]func(req *http.Request, timestamp string) string{
+	"method":    func(req *http.Request, _ string) string { return strings.ToUpper(req.Method) },
+	"host":      func(req *http.Request, _ string) string { return strings.ToLower(req.Host) },
+	"path":      func(req *http.Request, _ string) string { return req.URL.EscapedPath() },
+	"query":     func(req *http.Request, _ string) string { return req.URL.RawQuery },
+	"timestamp": func(_ *http.Request, timestamp string) string { return timestamp },
+}
+
+type Sign struct {
+	rule            *types.Rule
+	key             *key.Key
+	hash            func() hash.Hash
+	header          string
+	timestampHeader string
+	components      []string
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	signingKey, err := key.New(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &Sign{
+		rule:            &rule,
+		key:             signingKey,
+		header:          rule.Header,
+		timestampHeader: rule.TimestampHeader,
+		components:      rule.Values,
+	}
+
+	if handler.header == "" {
+		handler.header = defaultHeader
+	}
+
+	if handler.timestampHeader == "" {
+		handler.timestampHeader = defaultTimestampHeader
+	}
+
+	if len(handler.components) == 0 {
+		handler.components = defaultComponents
+	}
+
+	switch rule.Algorithm {
+	case "", types.HMACSHA256:
+		handler.hash = sha256.New
+	case types.HMACSHA512:
+		handler.hash = sha512.New
+	}
+
+	return handler, nil
+}
+
+func (s *Sign) Validate() error {
+	if len(s.rule.Filters) > 0 {
+		return fmt.Errorf("%w: not supported by Sign rules", types.ErrInvalidFilter)
+	}
+
+	if len(s.key.Bytes()) == 0 {
+		return fmt.Errorf("%w: Key", types.ErrMissingRequiredFields)
+	}
+
+	if s.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	if s.hash == nil {
+		return fmt.Errorf("%w: %q", types.ErrInvalidAlgorithm, s.rule.Algorithm)
+	}
+
+	for _, component := range s.components {
+		if _, ok := components[component]; ok {
+			continue
+		}
+
+		if name := strings.TrimPrefix(component, headerComponent); name == component || name == "" {
+			return fmt.Errorf("%w: %q", types.ErrUnknownField, component)
+		}
+	}
+
+	return nil
+}
+
+func (s *Sign) Handle(_ http.ResponseWriter, req *http.Request) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(s.hash, s.key.Bytes())
+	mac.Write([]byte(s.canonical(req, timestamp)))
+
+	req.Header.Set(s.header, hex.EncodeToString(mac.Sum(nil)))
+	req.Header.Set(s.timestampHeader, timestamp)
+}
+
+// canonical returns the signed string: the value of each component on its own line.
+func (s *Sign) canonical(req *http.Request, timestamp string) string {
+	lines := make([]string, 0, len(s.components))
+
+	for _, component := range s.components {
+		if name, ok := strings.CutPrefix(component, headerComponent); ok {
+			values := make([]string, 0, 1)
+			for _, value := range req.Header.Values(name) {
+				values = append(values, strings.TrimSpace(value))
+			}
+
+			lines = append(lines, strings.ToLower(name)+":"+strings.Join(values, ","))
+
+			continue
+		}
+
+		lines = append(lines, components[component](req, timestamp))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,158 @@
+package sign_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/sign"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestSignHandler(t *testing.T) {
+	t.Parallel()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("file-secret\n"), 0o600))
+
+	testCases := []struct {
+		name            string
+		rule            types.Rule
+		hash            func() hash.Hash
+		key             string
+		header          string
+		timestampHeader string
+		// canonical is the expected canonical string, %t standing for the timestamp.
+		canonical string
+	}{
+		{
+			name:            "default components",
+			rule:            types.Rule{Key: "secret"},
+			hash:            sha256.New,
+			key:             "secret",
+			header:          "X-Signature",
+			timestampHeader: "X-Signature-Timestamp",
+			canonical:       "POST\n/api/v1/items%20list\n%t",
+		},
+		{
+			name: "selected components with SHA-512",
+			rule: types.Rule{
+				KeyFile:         keyFile,
+				Algorithm:       types.HMACSHA512,
+				Header:          "X-Gateway-Signature",
+				TimestampHeader: "X-Gateway-Timestamp",
+				Values:          []string{"timestamp", "method", "host", "path", "query", "header:X-Tenant", "header:Content-Type", "header:X-Missing"},
+			},
+			hash:            sha512.New,
+			key:             "file-secret",
+			header:          "X-Gateway-Signature",
+			timestampHeader: "X-Gateway-Timestamp",
+			canonical:       "%t\nPOST\nexample.com\n/api/v1/items%20list\nb=2&a=1\nx-tenant:acme,other\ncontent-type:application/json\nx-missing:",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://Example.com/api/v1/items%20list?b=2&a=1", nil)
+			require.NoError(t, err)
+
+			req.Header.Add("X-Tenant", " acme ")
+			req.Header.Add("X-Tenant", "other")
+			req.Header.Set("Content-Type", "application/json")
+
+			handler, err := sign.New(test.rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			before := time.Now().Unix()
+			handler.Handle(nil, req)
+
+			timestamp := req.Header.Get(test.timestampHeader)
+			unix, err := strconv.ParseInt(timestamp, 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, true, unix >= before && unix <= time.Now().Unix())
+
+			mac := hmac.New(test.hash, []byte(test.key))
+			mac.Write([]byte(strings.ReplaceAll(test.canonical, "%t", timestamp)))
+
+			assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), req.Header.Get(test.header))
+			// Signed headers are left untouched.
+			assert.Equal(t, []string{" acme ", "other"}, req.Header.Values("X-Tenant"))
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "missing key",
+			rule:    types.Rule{},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{Key: "secret", SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "unknown algorithm",
+			rule:    types.Rule{Key: "secret", Algorithm: "HMAC-MD5"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown component",
+			rule:    types.Rule{Key: "secret", Values: []string{"method", "body"}},
+			wantErr: true,
+		},
+		{
+			name:    "header component without name",
+			rule:    types.Rule{Key: "secret", Values: []string{"header:"}},
+			wantErr: true,
+		},
+		{
+			name:    "filters",
+			rule:    types.Rule{Key: "secret", Filters: []string{"lower"}},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Key: "secret", Algorithm: types.HMACSHA512, Values: []string{"method", "header:Date"}},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := sign.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package sigv4
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/body"
+	"github.com/tomMoulard/htransformation/pkg/utils/key"
+	"github.com/tomMoulard/htransformation/pkg/utils/sigv4"
+)
+
+const (
+	defaultService     = "s3"
+	defaultMaxBodySize = 10 << 20
+)
+
+type SigV4 struct {
+	rule        *types.Rule
+	secret      *key.Key
+	service     string
+	maxBodySize int64
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	secret, err := key.New(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	service := rule.Service
+	if service == "" {
+		service = defaultService
+	}
+
+	maxBodySize := rule.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+
+	return &SigV4{rule: &rule, secret: secret, service: service, maxBodySize: maxBodySize}, nil
+}
+
+func (s *SigV4) Validate() error {
+	if len(s.rule.Filters) > 0 {
+		return fmt.Errorf("%w: not supported by SigV4 rules", types.ErrInvalidFilter)
+	}
+
+	if s.rule.AccessKeyID == "" || s.rule.Region == "" || len(s.secret.Bytes()) == 0 {
+		return fmt.Errorf("%w: AccessKeyID, Key and Region", types.ErrMissingRequiredFields)
+	}
+
+	if s.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	return nil
+}
+
+func (s *SigV4) Handle(_ http.ResponseWriter, req *http.Request) {
+	payloadHash := sigv4.UnsignedPayload
+
+	if !s.rule.UnsignedPayload {
+		content, complete, err := body.Read(req, s.maxBodySize)
+		if err != nil {
+			s.reject(req, http.StatusBadRequest)
+
+			return
+		}
+
+		if !complete {
+			s.reject(req, http.StatusRequestEntityTooLarge)
+
+			return
+		}
+
+		payloadHash = sigv4.HashPayload(content)
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	credentials := sigv4.Credentials{AccessKeyID: s.rule.AccessKeyID, SecretAccessKey: string(s.secret.Bytes())}
+	sigv4.Sign(req, credentials, s.rule.Region, s.service, payloadHash, time.Now())
+}
+
+// reject rejects the request with RejectStatus, or statusCode when it is not set.
+func (s *SigV4) reject(req *http.Request, statusCode int) {
+	types.RejectRequest(req, s.rule.RejectStatus, statusCode)
+}
@@ -0,0 +1,229 @@
+package sigv4_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/sigv4"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	sigv4util "github.com/tomMoulard/htransformation/pkg/utils/sigv4"
+)
+
+func TestSigV4Handler(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"hello": "world"}`
+
+	testCases := []struct {
+		name                string
+		rule                types.Rule
+		body                string
+		service             string
+		expectedPayloadHash string
+	}{
+		{
+			name:                "signed payload",
+			rule:                types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "eu-west-1"},
+			body:                body,
+			service:             "s3",
+			expectedPayloadHash: sigv4util.HashPayload([]byte(body)),
+		},
+		{
+			name:                "signed empty payload",
+			rule:                types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "eu-west-1", Service: "execute-api"},
+			service:             "execute-api",
+			expectedPayloadHash: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		},
+		{
+			name:                "unsigned payload",
+			rule:                types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "us-east-1", UnsignedPayload: true},
+			body:                body,
+			service:             "s3",
+			expectedPayloadHash: "UNSIGNED-PAYLOAD",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var reqBody io.Reader
+			if test.body != "" {
+				reqBody = strings.NewReader(test.body)
+			}
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPut, "http://minio:9000/bucket/my%20object?x-id=PutObject", reqBody)
+			require.NoError(t, err)
+
+			handler, err := sigv4.New(test.rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			handler.Handle(nil, req)
+
+			assert.Equal(t, test.expectedPayloadHash, req.Header.Get("X-Amz-Content-Sha256"))
+
+			signedAt, err := time.Parse("20060102T150405Z", req.Header.Get("X-Amz-Date"))
+			require.NoError(t, err)
+
+			expected, err := http.NewRequestWithContext(t.Context(), http.MethodPut, "http://minio:9000/bucket/my%20object?x-id=PutObject", nil)
+			require.NoError(t, err)
+
+			expected.Header.Set("X-Amz-Content-Sha256", test.expectedPayloadHash)
+			sigv4util.Sign(expected, sigv4util.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"},
+				test.rule.Region, test.service, test.expectedPayloadHash, signedAt)
+
+			assert.Equal(t, expected.Header.Get("Authorization"), req.Header.Get("Authorization"))
+
+			// The body is still forwarded.
+			if test.body != "" {
+				forwarded, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.Equal(t, test.body, string(forwarded))
+			}
+		})
+	}
+}
+
+// failingReader fails reading the body.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestBodyRejection(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		rule                 types.Rule
+		body                 io.Reader
+		expectedRejectStatus int
+	}{
+		{
+			name:                 "body within the limit",
+			rule:                 types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "us-east-1", MaxBodySize: 5},
+			body:                 strings.NewReader("hello"),
+			expectedRejectStatus: 0,
+		},
+		{
+			name:                 "body too large",
+			rule:                 types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "us-east-1", MaxBodySize: 4},
+			body:                 strings.NewReader("hello"),
+			expectedRejectStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "body too large with status",
+			rule: types.Rule{
+				AccessKeyID:  "AKIDEXAMPLE",
+				Key:          "secret",
+				Region:       "us-east-1",
+				MaxBodySize:  4,
+				RejectStatus: http.StatusBadRequest,
+			},
+			body:                 strings.NewReader("hello"),
+			expectedRejectStatus: http.StatusBadRequest,
+		},
+		{
+			name:                 "unreadable body",
+			rule:                 types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "us-east-1"},
+			body:                 failingReader{},
+			expectedRejectStatus: http.StatusBadRequest,
+		},
+		{
+			name:                 "unreadable body with status",
+			rule:                 types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "us-east-1", RejectStatus: http.StatusBadGateway},
+			body:                 failingReader{},
+			expectedRejectStatus: http.StatusBadGateway,
+		},
+		{
+			name:                 "unsigned payload is not read",
+			rule:                 types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "us-east-1", MaxBodySize: 4, UnsignedPayload: true},
+			body:                 strings.NewReader("hello"),
+			expectedRejectStatus: 0,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPut, "http://minio:9000/bucket/object", test.body)
+			require.NoError(t, err)
+
+			state := &types.State{}
+			req = types.WithState(req, state)
+
+			handler, err := sigv4.New(test.rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			handler.Handle(nil, req)
+
+			assert.Equal(t, test.expectedRejectStatus, state.RejectStatus)
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "missing access key",
+			rule:    types.Rule{Key: "secret", Region: "us-east-1"},
+			wantErr: true,
+		},
+		{
+			name:    "missing secret key",
+			rule:    types.Rule{AccessKeyID: "AKIDEXAMPLE", Region: "us-east-1"},
+			wantErr: true,
+		},
+		{
+			name:    "missing region",
+			rule:    types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret"},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "us-east-1", SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "filters",
+			rule:    types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "us-east-1", Filters: []string{"unknown"}},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{AccessKeyID: "AKIDEXAMPLE", Key: "secret", Region: "us-east-1", UnsignedPayload: true},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := sigv4.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
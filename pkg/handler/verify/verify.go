@@ -0,0 +1,237 @@
+package verify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/body"
+	"github.com/tomMoulard/htransformation/pkg/utils/key"
+)
+
+const (
+	defaultMaxBodySize = 1 << 20
+	defaultTolerance   = 5 * time.Minute
+	defaultTagHeader   = "X-Signature-Verified"
+	defaultSep         = "."
+	// timestampKey is the key of the timestamp in a signature header holding a list.
+	timestampKey = "t"
+)
+
+// Results of the verification, written in the tag header.
+const (
+	resultValid    = "valid"
+	resultMissing  = "missing"
+	resultInvalid  = "invalid"
+	resultExpired  = "expired"
+	resultTooLarge = "too-large"
+)
+
+type Verify struct {
+	rule        *types.Rule
+	key         *key.Key
+	hash        func() hash.Hash
+	maxBodySize int64
+	tolerance   time.Duration
+	tagHeader   string
+	sep         string
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	verificationKey, err := key.New(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := &Verify{
+		rule:        &rule,
+		key:         verificationKey,
+		maxBodySize: rule.MaxBodySize,
+		tolerance:   defaultTolerance,
+		tagHeader:   rule.TagHeader,
+		sep:         rule.Sep,
+	}
+
+	if handler.maxBodySize <= 0 {
+		handler.maxBodySize = defaultMaxBodySize
+	}
+
+	if handler.tagHeader == "" {
+		handler.tagHeader = defaultTagHeader
+	}
+
+	if handler.sep == "" {
+		handler.sep = defaultSep
+	}
+
+	if rule.Tolerance != "" {
+		handler.tolerance, err = time.ParseDuration(rule.Tolerance)
+		if err != nil || handler.tolerance <= 0 {
+			return nil, fmt.Errorf("%w: Tolerance %q", types.ErrInvalidDuration, rule.Tolerance)
+		}
+	}
+
+	switch rule.Algorithm {
+	case "", types.HMACSHA256:
+		handler.hash = sha256.New
+	case types.HMACSHA512:
+		handler.hash = sha512.New
+	}
+
+	return handler, nil
+}
+
+func (v *Verify) Validate() error {
+	// Filters could turn a tag into another one, such as trimprefix:in on invalid.
+	if len(v.rule.Filters) > 0 {
+		return fmt.Errorf("%w: not supported by Verify rules", types.ErrInvalidFilter)
+	}
+
+	if v.rule.Header == "" || len(v.key.Bytes()) == 0 {
+		return fmt.Errorf("%w: Header and Key", types.ErrMissingRequiredFields)
+	}
+
+	if v.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	if v.hash == nil {
+		return fmt.Errorf("%w: %q", types.ErrInvalidAlgorithm, v.rule.Algorithm)
+	}
+
+	switch v.rule.OnFailure {
+	case "", types.Reject, types.Tag:
+		return nil
+	default:
+		return types.ErrInvalidFailureAction
+	}
+}
+
+func (v *Verify) Handle(_ http.ResponseWriter, req *http.Request) {
+	if v.rule.OnFailure == types.Tag {
+		// The tag is always written, so that clients cannot spoof it.
+		req.Header.Set(v.tagHeader, v.verify(req))
+
+		return
+	}
+
+	if v.verify(req) != resultValid {
+		types.RejectRequest(req, v.rule.RejectStatus, http.StatusUnauthorized)
+	}
+}
+
+// verify returns the result of the verification of req.
+func (v *Verify) verify(req *http.Request) string {
+	signatures, timestamp := v.parseHeaders(req)
+	if len(signatures) == 0 {
+		return resultMissing
+	}
+
+	if v.timestamped() {
+		if result := v.checkTimestamp(timestamp); result != resultValid {
+			return result
+		}
+	}
+
+	content, complete, err := body.Read(req, v.maxBodySize)
+	if err != nil {
+		return resultInvalid
+	}
+
+	if !complete {
+		return resultTooLarge
+	}
+
+	mac := hmac.New(v.hash, v.key.Bytes())
+	if v.timestamped() {
+		mac.Write([]byte(timestamp + v.sep))
+	}
+
+	mac.Write(content)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		if hmac.Equal(expected, decode(signature)) {
+			return resultValid
+		}
+	}
+
+	return resultInvalid
+}
+
+// timestamped reports whether the signed content starts with a timestamp.
+func (v *Verify) timestamped() bool {
+	return v.rule.SignatureParam != "" || v.rule.TimestampHeader != ""
+}
+
+// parseHeaders returns the signatures and the timestamp sent with req.
+func (v *Verify) parseHeaders(req *http.Request) ([]string, string) {
+	header := req.Header.Get(v.rule.Header)
+	if header == "" {
+		return nil, ""
+	}
+
+	timestamp := ""
+	if v.rule.TimestampHeader != "" {
+		timestamp = req.Header.Get(v.rule.TimestampHeader)
+	}
+
+	if v.rule.SignatureParam == "" {
+		return []string{strings.TrimPrefix(header, v.rule.SignaturePrefix)}, timestamp
+	}
+
+	// The header is a list of key=value pairs: t=1492774577,v1=5257a869...,v1=6ffbb59b...
+	var signatures []string
+
+	for _, pair := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+
+		switch name {
+		case v.rule.SignatureParam:
+			signatures = append(signatures, value)
+		case timestampKey:
+			timestamp = value
+		}
+	}
+
+	return signatures, timestamp
+}
+
+func (v *Verify) checkTimestamp(timestamp string) string {
+	if timestamp == "" {
+		return resultMissing
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return resultInvalid
+	}
+
+	if age := time.Since(time.Unix(unix, 0)); age > v.tolerance || age < -v.tolerance {
+		return resultExpired
+	}
+
+	return resultValid
+}
+
+// decode decodes a hexadecimal or base64 signature, or returns nil.
+func decode(signature string) []byte {
+	if decoded, err := hex.DecodeString(signature); err == nil {
+		return decoded
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(signature); err == nil {
+		return decoded
+	}
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package verify_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/verify"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+const payload = `{"event": "push"}`
+
+func sign(hashFunc func() hash.Hash, key, content string) []byte {
+	mac := hmac.New(hashFunc, []byte(key))
+	mac.Write([]byte(content))
+
+	return mac.Sum(nil)
+}
+
+func TestVerifyHandler(t *testing.T) {
+	t.Parallel()
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	github := types.Rule{Header: "X-Hub-Signature-256", SignaturePrefix: "sha256=", Key: "webhook-secret"}
+	stripe := types.Rule{Header: "Stripe-Signature", SignatureParam: "v1", Key: "whsec"}
+	tagged := types.Rule{Header: "X-Hub-Signature-256", SignaturePrefix: "sha256=", Key: "webhook-secret", OnFailure: types.Tag}
+
+	testCases := []struct {
+		name                 string
+		rule                 types.Rule
+		body                 string
+		requestHeaders       map[string]string
+		expectedHeaders      map[string]string
+		expectedRejectStatus int
+	}{
+		{
+			name: "GitHub documentation example",
+			rule: types.Rule{Header: "X-Hub-Signature-256", SignaturePrefix: "sha256=", Key: "It's a Secret to Everybody"},
+			body: "Hello, World!",
+			requestHeaders: map[string]string{
+				"X-Hub-Signature-256": "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+			},
+		},
+		{
+			name: "valid signature",
+			rule: github,
+			body: payload,
+			requestHeaders: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(sha256.New, "webhook-secret", payload)),
+			},
+		},
+		{
+			name: "invalid signature",
+			rule: github,
+			body: payload + " ",
+			requestHeaders: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(sha256.New, "webhook-secret", payload)),
+			},
+			expectedRejectStatus: http.StatusUnauthorized,
+		},
+		{
+			name:                 "missing signature",
+			rule:                 types.Rule{Header: "X-Hub-Signature-256", Key: "webhook-secret", RejectStatus: http.StatusForbidden},
+			body:                 payload,
+			expectedRejectStatus: http.StatusForbidden,
+		},
+		{
+			name: "base64 signature with SHA-512",
+			rule: types.Rule{Header: "X-Hmac", Key: "webhook-secret", Algorithm: types.HMACSHA512},
+			body: payload,
+			requestHeaders: map[string]string{
+				"X-Hmac": base64.StdEncoding.EncodeToString(sign(sha512.New, "webhook-secret", payload)),
+			},
+		},
+		{
+			name: "timestamped signature list",
+			rule: stripe,
+			body: payload,
+			requestHeaders: map[string]string{
+				"Stripe-Signature": "t=" + now + ",v1=00ff,v1=" + hex.EncodeToString(sign(sha256.New, "whsec", now+"."+payload)),
+			},
+		},
+		{
+			name: "stale timestamp",
+			rule: stripe,
+			body: payload,
+			requestHeaders: map[string]string{
+				"Stripe-Signature": "t=" + stale + ",v1=" + hex.EncodeToString(sign(sha256.New, "whsec", stale+"."+payload)),
+			},
+			expectedRejectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "stale timestamp within tolerance",
+			rule: types.Rule{Header: "Stripe-Signature", SignatureParam: "v1", Key: "whsec", Tolerance: "2h"},
+			body: payload,
+			requestHeaders: map[string]string{
+				"Stripe-Signature": "t=" + stale + ",v1=" + hex.EncodeToString(sign(sha256.New, "whsec", stale+"."+payload)),
+			},
+		},
+		{
+			name: "timestamp header",
+			rule: types.Rule{Header: "X-Signature", TimestampHeader: "X-Timestamp", Sep: ":", Key: "webhook-secret"},
+			body: payload,
+			requestHeaders: map[string]string{
+				"X-Timestamp": now,
+				"X-Signature": hex.EncodeToString(sign(sha256.New, "webhook-secret", now+":"+payload)),
+			},
+		},
+		{
+			name: "missing timestamp",
+			rule: types.Rule{Header: "X-Signature", TimestampHeader: "X-Timestamp", Key: "webhook-secret"},
+			body: payload,
+			requestHeaders: map[string]string{
+				"X-Signature": hex.EncodeToString(sign(sha256.New, "webhook-secret", payload)),
+			},
+			expectedRejectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "body too large",
+			rule: types.Rule{Header: "X-Hub-Signature-256", SignaturePrefix: "sha256=", Key: "webhook-secret", MaxBodySize: 4},
+			body: payload,
+			requestHeaders: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(sha256.New, "webhook-secret", payload)),
+			},
+			expectedRejectStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "tag valid signature",
+			rule: tagged,
+			body: payload,
+			requestHeaders: map[string]string{
+				"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(sha256.New, "webhook-secret", payload)),
+			},
+			expectedHeaders: map[string]string{"X-Signature-Verified": "valid"},
+		},
+		{
+			name: "tag invalid signature",
+			rule: tagged,
+			body: payload,
+			requestHeaders: map[string]string{
+				"X-Hub-Signature-256":  "sha256=00ff",
+				"X-Signature-Verified": "valid",
+			},
+			expectedHeaders: map[string]string{"X-Signature-Verified": "invalid"},
+		},
+		{
+			name: "tag body too large",
+			rule: types.Rule{Header: "X-Sig", Key: "webhook-secret", MaxBodySize: 4, OnFailure: types.Tag, TagHeader: "X-Webhook"},
+			body: payload,
+			requestHeaders: map[string]string{
+				"X-Sig": "00ff",
+			},
+			expectedHeaders: map[string]string{"X-Webhook": "too-large"},
+		},
+		{
+			name:            "tag missing signature",
+			rule:            tagged,
+			body:            payload,
+			expectedHeaders: map[string]string{"X-Signature-Verified": "missing"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://example.com/webhook", strings.NewReader(test.body))
+			require.NoError(t, err)
+
+			for hName, hVal := range test.requestHeaders {
+				req.Header.Set(hName, hVal)
+			}
+
+			state := &types.State{}
+			req = types.WithState(req, state)
+
+			handler, err := verify.New(test.rule)
+			require.NoError(t, err)
+			require.NoError(t, handler.Validate())
+
+			handler.Handle(nil, req)
+
+			assert.Equal(t, test.expectedRejectStatus, state.RejectStatus)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equalf(t, hVal, req.Header.Get(hName), "header %q", hName)
+			}
+
+			// The body is restored for the backend.
+			forwarded, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, test.body, string(forwarded))
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		rule       types.Rule
+		wantNewErr bool
+		wantErr    bool
+	}{
+		{
+			name:    "missing header",
+			rule:    types.Rule{Key: "secret"},
+			wantErr: true,
+		},
+		{
+			name:    "missing key",
+			rule:    types.Rule{Header: "X-Signature"},
+			wantErr: true,
+		},
+		{
+			name:    "on response",
+			rule:    types.Rule{Header: "X-Signature", Key: "secret", SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported algorithm",
+			rule:    types.Rule{Header: "X-Signature", Key: "secret", Algorithm: types.Ed25519},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported failure action",
+			rule:    types.Rule{Header: "X-Signature", Key: "secret", OnFailure: types.Skip},
+			wantErr: true,
+		},
+		{
+			name:    "filters",
+			rule:    types.Rule{Header: "X-Signature", Key: "secret", OnFailure: types.Tag, Filters: []string{"trimprefix:in"}},
+			wantErr: true,
+		},
+		{
+			name:       "invalid tolerance",
+			rule:       types.Rule{Header: "X-Signature", Key: "secret", Tolerance: "-5m"},
+			wantNewErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Header: "X-Signature", Key: "secret", OnFailure: types.Tag, Tolerance: "10m"},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := verify.New(test.rule)
+			if test.wantNewErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package xfcc
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/xfcc"
+)
+
+// fields maps the optional element keys to their pairs. Hash is always written.
+var fields = map[string]func(certs []*x509.Certificate) []xfcc.Pair{
+	"Subject": func(certs []*x509.Certificate) []xfcc.Pair {
+		return []xfcc.Pair{{Key: "Subject", Value: certs[0].Subject.String()}}
+	},
+	"URI": func(certs []*x509.Certificate) []xfcc.Pair {
+		pairs := make([]xfcc.Pair, 0, len(certs[0].URIs))
+		for _, uri := range certs[0].URIs {
+			pairs = append(pairs, xfcc.Pair{Key: "URI", Value: uri.String()})
+		}
+
+		return pairs
+	},
+	"DNS": func(certs []*x509.Certificate) []xfcc.Pair {
+		pairs := make([]xfcc.Pair, 0, len(certs[0].DNSNames))
+		for _, name := range certs[0].DNSNames {
+			pairs = append(pairs, xfcc.Pair{Key: "DNS", Value: name})
+		}
+
+		return pairs
+	},
+	"Cert": func(certs []*x509.Certificate) []xfcc.Pair {
+		return []xfcc.Pair{{Key: "Cert", Value: encodePEM(certs[:1])}}
+	},
+	"Chain": func(certs []*x509.Certificate) []xfcc.Pair {
+		return []xfcc.Pair{{Key: "Chain", Value: encodePEM(certs)}}
+	},
+}
+
+// encodePEM returns the URL-encoded PEM of certs.
+func encodePEM(certs []*x509.Certificate) string {
+	var encoded strings.Builder
+	for _, cert := range certs {
+		encoded.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+
+	return url.PathEscape(encoded.String())
+}
+
+type XFCC struct {
+	rule    *types.Rule
+	header  string
+	mode    types.XFCCMode
+	filters *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	header := rule.Header
+	if header == "" {
+		header = xfcc.Header
+	}
+
+	mode := rule.Mode
+	if mode == "" {
+		mode = types.XFCCSanitizeSet
+	}
+
+	return &XFCC{rule: &rule, header: header, mode: mode, filters: filter.New(rule.Filters)}, nil
+}
+
+func (x *XFCC) Validate() error {
+	if err := x.filters.Validate(); err != nil {
+		return err
+	}
+
+	if x.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	switch x.mode {
+	case types.XFCCSanitize, types.XFCCForward, types.XFCCAlwaysForward, types.XFCCAppendForward, types.XFCCSanitizeSet:
+	default:
+		return fmt.Errorf("%w: %q", types.ErrInvalidMode, x.mode)
+	}
+
+	for _, field := range x.rule.Values {
+		if _, ok := fields[field]; !ok {
+			return fmt.Errorf("%w: %q", types.ErrUnknownField, field)
+		}
+	}
+
+	return nil
+}
+
+func (x *XFCC) Handle(_ http.ResponseWriter, req *http.Request) {
+	mutualTLS := req.TLS != nil && len(req.TLS.PeerCertificates) > 0
+
+	switch {
+	case x.mode == types.XFCCAlwaysForward:
+	case x.mode == types.XFCCSanitize || !mutualTLS:
+		req.Header.Del(x.header)
+	case x.mode == types.XFCCAppendForward:
+		current := x.element(req.TLS.PeerCertificates)
+		if incoming := strings.Join(req.Header.Values(x.header), ","); incoming != "" {
+			current = incoming + "," + current
+		}
+
+		req.Header.Set(x.header, current)
+	case x.mode == types.XFCCSanitizeSet:
+		req.Header.Set(x.header, x.element(req.TLS.PeerCertificates))
+	}
+}
+
+// element returns the formatted element describing the client certificate,
+// filtered.
+func (x *XFCC) element(certs []*x509.Certificate) string {
+	element := xfcc.Element{}
+
+	if x.rule.Value != "" {
+		element = append(element, xfcc.Pair{Key: "By", Value: x.rule.Value})
+	}
+
+	sum := sha256.Sum256(certs[0].Raw)
+	element = append(element, xfcc.Pair{Key: "Hash", Value: hex.EncodeToString(sum[:])})
+
+	for _, field := range x.rule.Values {
+		element = append(element, fields[field](certs)...)
+	}
+
+	return x.filters.Apply(xfcc.Format([]xfcc.Element{element}))
+}
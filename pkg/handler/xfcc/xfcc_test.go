@@ -0,0 +1,260 @@
+package xfcc_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/xfcc"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	xfccutil "github.com/tomMoulard/htransformation/pkg/utils/xfcc"
+)
+
+// clientCertificate returns a self-signed client certificate.
+func clientCertificate(t *testing.T) *x509.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	spiffe, err := url.Parse("spiffe://example.com/client")
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client", Organization: []string{"Acme"}},
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC),
+		DNSNames:     []string{"client.example.com", "client.example.org"},
+		URIs:         []*url.URL{spiffe},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert
+}
+
+func TestXFCCHandler(t *testing.T) {
+	t.Parallel()
+
+	cert := clientCertificate(t)
+	sum := sha256.Sum256(cert.Raw)
+	hash := "Hash=" + hex.EncodeToString(sum[:])
+
+	testCases := []struct {
+		name          string
+		rule          types.Rule
+		mutualTLS     bool
+		incoming      string
+		expectedValue string
+	}{
+		{
+			name:          "set on mutual TLS",
+			rule:          types.Rule{Value: "spiffe://example.com/gateway"},
+			mutualTLS:     true,
+			incoming:      "Hash=spoofed",
+			expectedValue: "By=spiffe://example.com/gateway;" + hash,
+		},
+		{
+			name:          "set selected fields",
+			rule:          types.Rule{Values: []string{"Subject", "URI", "DNS"}},
+			mutualTLS:     true,
+			expectedValue: hash + `;Subject="CN=client,O=Acme";URI=spiffe://example.com/client;DNS=client.example.com;DNS=client.example.org`,
+		},
+		{
+			name:          "sanitize without mutual TLS",
+			rule:          types.Rule{},
+			incoming:      "Hash=spoofed",
+			expectedValue: "",
+		},
+		{
+			name:          "append to incoming header",
+			rule:          types.Rule{Mode: types.XFCCAppendForward},
+			mutualTLS:     true,
+			incoming:      "By=spiffe://edge;Hash=abc",
+			expectedValue: "By=spiffe://edge;Hash=abc," + hash,
+		},
+		{
+			name:          "append filtered element",
+			rule:          types.Rule{Mode: types.XFCCAppendForward, Filters: []string{"trimprefix:Hash="}},
+			mutualTLS:     true,
+			incoming:      "Hash=abc",
+			expectedValue: "Hash=abc," + hex.EncodeToString(sum[:]),
+		},
+		{
+			name:          "append without incoming header",
+			rule:          types.Rule{Mode: types.XFCCAppendForward},
+			mutualTLS:     true,
+			expectedValue: hash,
+		},
+		{
+			name:          "append without mutual TLS",
+			rule:          types.Rule{Mode: types.XFCCAppendForward},
+			incoming:      "Hash=abc",
+			expectedValue: "",
+		},
+		{
+			name:          "forward on mutual TLS",
+			rule:          types.Rule{Mode: types.XFCCForward},
+			mutualTLS:     true,
+			incoming:      "Hash=abc",
+			expectedValue: "Hash=abc",
+		},
+		{
+			name:          "forward without mutual TLS",
+			rule:          types.Rule{Mode: types.XFCCForward},
+			incoming:      "Hash=abc",
+			expectedValue: "",
+		},
+		{
+			name:          "always forward",
+			rule:          types.Rule{Mode: types.XFCCAlwaysForward},
+			incoming:      "Hash=abc",
+			expectedValue: "Hash=abc",
+		},
+		{
+			name:          "sanitize",
+			rule:          types.Rule{Mode: types.XFCCSanitize},
+			mutualTLS:     true,
+			incoming:      "Hash=abc",
+			expectedValue: "",
+		},
+		{
+			name:          "custom header",
+			rule:          types.Rule{Header: "X-Client-Identity"},
+			mutualTLS:     true,
+			expectedValue: hash,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://example.com/foo", nil)
+			require.NoError(t, err)
+
+			if test.mutualTLS {
+				req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+			}
+
+			if test.incoming != "" {
+				req.Header.Set("X-Forwarded-Client-Cert", test.incoming)
+			}
+
+			handler, err := xfcc.New(test.rule)
+			require.NoError(t, err)
+
+			handler.Handle(nil, req)
+
+			header := test.rule.Header
+			if header == "" {
+				header = "X-Forwarded-Client-Cert"
+			}
+
+			assert.Equal(t, test.expectedValue, req.Header.Get(header))
+		})
+	}
+}
+
+func TestXFCCHandlerCert(t *testing.T) {
+	t.Parallel()
+
+	cert := clientCertificate(t)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://example.com/foo", nil)
+	require.NoError(t, err)
+
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert, cert}}
+
+	handler, err := xfcc.New(types.Rule{Values: []string{"Cert", "Chain"}})
+	require.NoError(t, err)
+
+	handler.Handle(nil, req)
+
+	elements, err := xfccutil.Parse(req.Header.Get("X-Forwarded-Client-Cert"))
+	require.NoError(t, err)
+
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+
+	for field, expected := range map[string]string{"Cert": certPEM, "Chain": certPEM + certPEM} {
+		values := elements[0].Values(field)
+		if len(values) != 1 {
+			t.Fatalf("expected one %s, got %v", field, values)
+		}
+
+		decoded, err := url.PathUnescape(values[0])
+		require.NoError(t, err)
+		assert.Equalf(t, expected, decoded, "field %q", field)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "on response",
+			rule:    types.Rule{SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			rule:    types.Rule{Mode: "Append"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			rule:    types.Rule{Values: []string{"Serial"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown filter",
+			rule:    types.Rule{Filters: []string{"bogus"}},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Mode: types.XFCCAppendForward, Values: []string{"Subject", "URI"}},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := xfcc.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package xfcctoheaders
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/filter"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/xfcc"
+)
+
+const defaultPrefix = "X-Client-Cert"
+
+// fields are the element keys copied into headers, used as header suffixes.
+var fields = []string{"By", "Hash", "Subject", "URI", "DNS", "Cert", "Chain"}
+
+type XFCCToHeaders struct {
+	rule   *types.Rule
+	prefix string
+	// selected are the fields written on the request, all of them by default.
+	selected []string
+	filters  *filter.Chain
+}
+
+func New(rule types.Rule) (types.Handler, error) {
+	prefix := rule.Header
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+
+	selected := rule.Values
+	if len(selected) == 0 {
+		selected = fields
+	}
+
+	return &XFCCToHeaders{rule: &rule, prefix: prefix, selected: selected, filters: filter.New(rule.Filters)}, nil
+}
+
+func (x *XFCCToHeaders) Validate() error {
+	if err := x.filters.Validate(); err != nil {
+		return err
+	}
+
+	if x.rule.SetOnResponse {
+		return types.ErrRequestOnly
+	}
+
+	switch x.rule.OnFailure {
+	case "", types.Skip, types.Reject:
+	default:
+		return types.ErrInvalidFailureAction
+	}
+
+	for _, field := range x.selected {
+		if !isField(field) {
+			return fmt.Errorf("%w: %q", types.ErrUnknownField, field)
+		}
+	}
+
+	return nil
+}
+
+func isField(name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (x *XFCCToHeaders) Handle(_ http.ResponseWriter, req *http.Request) {
+	// Every field header is removed first, so that clients cannot spoof them.
+	for _, field := range fields {
+		req.Header.Del(x.prefix + "-" + field)
+	}
+
+	incoming := strings.Join(req.Header.Values(xfcc.Header), ",")
+	if incoming == "" {
+		return
+	}
+
+	elements, err := xfcc.Parse(incoming)
+	if err != nil && x.rule.OnFailure == types.Reject {
+		types.RejectRequest(req, x.rule.RejectStatus, http.StatusBadRequest)
+
+		return
+	}
+
+	if x.rule.RemoveSource {
+		req.Header.Del(xfcc.Header)
+	}
+
+	if len(elements) == 0 {
+		return
+	}
+
+	sep := x.rule.Sep
+	if sep == "" {
+		sep = ","
+	}
+
+	// The last element describes the certificate of the closest client.
+	element := elements[len(elements)-1]
+
+	for _, field := range x.selected {
+		if values := element.Values(field); len(values) > 0 {
+			req.Header.Set(x.prefix+"-"+field, x.filters.Apply(strings.Join(values, sep)))
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package xfcctoheaders_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/handler/xfcctoheaders"
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+func TestXFCCToHeadersHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		rule                 types.Rule
+		requestHeaders       map[string]string
+		expectedHeaders      map[string]string
+		expectedRejectStatus int
+	}{
+		{
+			name: "copy fields",
+			rule: types.Rule{},
+			requestHeaders: map[string]string{
+				"X-Forwarded-Client-Cert": `By=spiffe://gw;Hash=abc;Subject="CN=client,O=Acme";URI=spiffe://client;DNS=a.example.com;DNS=b.example.com`,
+			},
+			expectedHeaders: map[string]string{
+				"X-Client-Cert-By":        "spiffe://gw",
+				"X-Client-Cert-Hash":      "abc",
+				"X-Client-Cert-Subject":   "CN=client,O=Acme",
+				"X-Client-Cert-URI":       "spiffe://client",
+				"X-Client-Cert-DNS":       "a.example.com,b.example.com",
+				"X-Forwarded-Client-Cert": `By=spiffe://gw;Hash=abc;Subject="CN=client,O=Acme";URI=spiffe://client;DNS=a.example.com;DNS=b.example.com`,
+			},
+		},
+		{
+			name: "copy selected fields of the last element",
+			rule: types.Rule{Header: "X-Peer", Values: []string{"Hash", "URI"}, Sep: " ", RemoveSource: true},
+			requestHeaders: map[string]string{
+				"X-Forwarded-Client-Cert": "Hash=abc;URI=spiffe://edge,Hash=def;URI=spiffe://client;URI=spiffe://other",
+			},
+			expectedHeaders: map[string]string{
+				"X-Peer-Hash":             "def",
+				"X-Peer-URI":              "spiffe://client spiffe://other",
+				"X-Peer-By":               "",
+				"X-Forwarded-Client-Cert": "",
+			},
+		},
+		{
+			name: "spoofed fields are removed",
+			rule: types.Rule{},
+			requestHeaders: map[string]string{
+				"X-Client-Cert-Subject": "CN=admin",
+			},
+			expectedHeaders: map[string]string{
+				"X-Client-Cert-Subject": "",
+			},
+		},
+		{
+			name: "malformed header skipped",
+			rule: types.Rule{},
+			requestHeaders: map[string]string{
+				"X-Forwarded-Client-Cert": `Subject="CN=client`,
+				"X-Client-Cert-Subject":   "CN=admin",
+			},
+			expectedHeaders: map[string]string{
+				"X-Client-Cert-Subject":   "",
+				"X-Forwarded-Client-Cert": `Subject="CN=client`,
+			},
+		},
+		{
+			name: "malformed header rejected",
+			rule: types.Rule{OnFailure: types.Reject},
+			requestHeaders: map[string]string{
+				"X-Forwarded-Client-Cert": `Subject="CN=client`,
+			},
+			expectedRejectStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://example.com/foo", nil)
+			require.NoError(t, err)
+
+			for hName, hVal := range test.requestHeaders {
+				req.Header.Set(hName, hVal)
+			}
+
+			state := &types.State{}
+			req = types.WithState(req, state)
+
+			handler, err := xfcctoheaders.New(test.rule)
+			require.NoError(t, err)
+
+			handler.Handle(nil, req)
+
+			for hName, hVal := range test.expectedHeaders {
+				assert.Equalf(t, hVal, req.Header.Get(hName), "header %q", hName)
+			}
+
+			assert.Equal(t, test.expectedRejectStatus, state.RejectStatus)
+		})
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		rule    types.Rule
+		wantErr bool
+	}{
+		{
+			name:    "on response",
+			rule:    types.Rule{SetOnResponse: true},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported failure action",
+			rule:    types.Rule{OnFailure: types.Default},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			rule:    types.Rule{Values: []string{"Serial"}},
+			wantErr: true,
+		},
+		{
+			name:    "valid rule",
+			rule:    types.Rule{Values: []string{"Hash", "Subject"}, OnFailure: types.Reject},
+			wantErr: false,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := xfcctoheaders.New(test.rule)
+			require.NoError(t, err)
+
+			err = handler.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
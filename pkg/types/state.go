@@ -0,0 +1,85 @@
+package types
+
+import (
+	"context"
+	"net/http"
+)
+
+// State holds the data of a request shared by its request and response rules.
+type State struct {
+	// StatusCode is the status code of the response, set before the response rules are applied.
+	StatusCode int
+	// RejectStatus is the status code the request is rejected with, 0 if it is not rejected.
+	RejectStatus int
+	// values are set by the request rules for the response rules.
+	values map[string]string
+	// original holds the request headers as received, before any rule changed them.
+	original http.Header
+}
+
+// SaveOriginal snapshots the headers of req, Host included, before the rules are applied.
+func (s *State) SaveOriginal(req *http.Request) {
+	s.original = req.Header.Clone()
+	if s.original == nil {
+		s.original = http.Header{}
+	}
+
+	if req.Host != "" {
+		s.original.Set("Host", req.Host)
+	}
+}
+
+// Original returns the request headers as received, or nil if they were not saved.
+func (s *State) Original() http.Header {
+	return s.original
+}
+
+// Reject stops the processing of the request, which is answered with statusCode
+// instead of being forwarded. The first rejection wins.
+func (s *State) Reject(statusCode int) {
+	if s.RejectStatus == 0 {
+		s.RejectStatus = statusCode
+	}
+}
+
+// RejectRequest rejects req with rejectStatus, the RejectStatus of a rule, or
+// with defaultStatus when it is not set.
+func RejectRequest(req *http.Request, rejectStatus, defaultStatus int) {
+	if rejectStatus == 0 {
+		rejectStatus = defaultStatus
+	}
+
+	GetState(req).Reject(rejectStatus)
+}
+
+// SetValue stores value under key, for the rest of the request and its response.
+func (s *State) SetValue(key, value string) {
+	if s.values == nil {
+		s.values = make(map[string]string)
+	}
+
+	s.values[key] = value
+}
+
+// Value returns the value stored under key, and whether it is set.
+func (s *State) Value(key string) (string, bool) {
+	value, ok := s.values[key]
+
+	return value, ok
+}
+
+type stateKey struct{}
+
+// WithState returns a shallow copy of req carrying state.
+func WithState(req *http.Request, state *State) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), stateKey{}, state))
+}
+
+// GetState returns the state carried by req, or an empty state if there is none.
+func GetState(req *http.Request) *State {
+	if state, ok := req.Context().Value(stateKey{}).(*State); ok {
+		return state
+	}
+
+	return &State{}
+}
@@ -22,6 +22,118 @@ const (
 	Rename RuleType = "Rename"
 	// RewriteValueRule will replace the value of a header with the provided value.
 	RewriteValueRule RuleType = "RewriteValueRule"
+	// QueryToHeader will copy the value of a query parameter into a header.
+	QueryToHeader RuleType = "QueryToHeader"
+	// HeaderToQuery will copy the value of a header into a query parameter.
+	HeaderToQuery RuleType = "HeaderToQuery"
+	// CookieToHeader will copy the value of a request cookie into a header.
+	CookieToHeader RuleType = "CookieToHeader"
+	// JWTClaims will copy claims of a bearer JWT into headers.
+	JWTClaims RuleType = "JWTClaims"
+	// BasicAuth will copy the username of Basic credentials into a header.
+	BasicAuth RuleType = "BasicAuth"
+	// ClientCert will copy fields of the TLS client certificate into headers.
+	ClientCert RuleType = "ClientCert"
+	// XFCC will build the X-Forwarded-Client-Cert header from the TLS client certificate.
+	XFCC RuleType = "XFCC"
+	// XFCCToHeaders will copy the fields of the X-Forwarded-Client-Cert header into headers.
+	XFCCToHeaders RuleType = "XFCCToHeaders"
+	// RequestID will generate a request ID when it is missing, and echo it on the response.
+	RequestID RuleType = "RequestID"
+	// Sign will sign the request with an HMAC for the upstream to authenticate it.
+	Sign RuleType = "Sign"
+	// MessageSignature will sign the request with HTTP Message Signatures (RFC 9421).
+	MessageSignature RuleType = "MessageSignature"
+	// SigV4 will sign the request with AWS Signature Version 4.
+	SigV4 RuleType = "SigV4"
+	// Verify will verify the HMAC signature of the request body, as sent with webhooks.
+	Verify RuleType = "Verify"
+	// Map will translate the value of a header into another header with a lookup table.
+	Map RuleType = "Map"
+	// Extract will copy the part of a header matched by a regexp into another header.
+	Extract RuleType = "Extract"
+)
+
+// FailureAction define what a rule does when its source is missing or invalid.
+type FailureAction string
+
+const (
+	// Skip will leave the request as is (default).
+	Skip FailureAction = "Skip"
+	// Reject will answer the request with RejectStatus instead of forwarding it.
+	Reject FailureAction = "Reject"
+	// Default will write the rule Value instead.
+	Default FailureAction = "Default"
+	// Tag will forward the request with the TagHeader describing the failure.
+	Tag FailureAction = "Tag"
+)
+
+// XFCCMode define how the XFCC rule handles an incoming X-Forwarded-Client-Cert header.
+type XFCCMode string
+
+const (
+	// XFCCSanitize will remove the header.
+	XFCCSanitize XFCCMode = "Sanitize"
+	// XFCCForward will forward the header as is on mutual TLS connections, and remove it otherwise.
+	XFCCForward XFCCMode = "Forward"
+	// XFCCAlwaysForward will forward the header as is.
+	XFCCAlwaysForward XFCCMode = "AlwaysForward"
+	// XFCCAppendForward will append the client certificate to the header on mutual TLS connections,
+	// and remove it otherwise.
+	XFCCAppendForward XFCCMode = "AppendForward"
+	// XFCCSanitizeSet will replace the header with the client certificate on mutual TLS connections,
+	// and remove it otherwise (default).
+	XFCCSanitizeSet XFCCMode = "SanitizeSet"
+)
+
+// IDFormat define the possible formats of generated request IDs.
+type IDFormat string
+
+const (
+	// UUIDv4 will generate random UUIDs (default).
+	UUIDv4 IDFormat = "UUIDv4"
+	// UUIDv7 will generate time ordered UUIDs.
+	UUIDv7 IDFormat = "UUIDv7"
+	// ULID will generate time ordered ULIDs.
+	ULID IDFormat = "ULID"
+)
+
+// Algorithm define the possible signature algorithms.
+type Algorithm string
+
+const (
+	// HMACSHA256 will sign with HMAC-SHA256 (default).
+	HMACSHA256 Algorithm = "HMAC-SHA256"
+	// HMACSHA512 will sign with HMAC-SHA512.
+	HMACSHA512 Algorithm = "HMAC-SHA512"
+	// Ed25519 will sign with Ed25519, using a PKCS #8 PEM encoded private key.
+	Ed25519 Algorithm = "Ed25519"
+)
+
+// MatchType define the possible ways to match the keys of a Map table.
+type MatchType string
+
+const (
+	// MatchExact will match when the value is equal to the key (default).
+	MatchExact MatchType = "Exact"
+	// MatchCaseInsensitive will match when the value is equal to the key, ignoring case.
+	MatchCaseInsensitive MatchType = "CaseInsensitive"
+	// MatchRegexp will match the value against the key as a regular expression.
+	MatchRegexp MatchType = "Regexp"
+)
+
+// PathMatchType define the possible ways to match the request path of a rule.
+type PathMatchType string
+
+const (
+	// PathExact will match when the request path is equal to the rule path (default).
+	PathExact PathMatchType = "Exact"
+	// PathPrefix will match when the request path starts with the rule path.
+	PathPrefix PathMatchType = "Prefix"
+	// PathGlob will match the request path against a shell pattern (see path.Match).
+	PathGlob PathMatchType = "Glob"
+	// PathRegexp will match the request path against a regular expression.
+	PathRegexp PathMatchType = "Regexp"
 )
 
 // Rule struct so that we get traefik config.
@@ -35,19 +147,142 @@ type Rule struct {
 	Value        string         `yaml:"Value"`
 	ValueReplace string         `yaml:"ValueReplace"` // value used as replacement in rewrite
 	Values       []string       `yaml:"Values"`       // values to join
+	Param        string         `yaml:"Param"`        // query parameter used by QueryToHeader and HeaderToQuery
+	RemoveSource bool           `yaml:"RemoveSource"` // remove the value source once it has been copied
+	Cookie       string         `yaml:"Cookie"`       // request cookie used by CookieToHeader
+	OnFailure    FailureAction  `yaml:"OnFailure"`    // what to do when the rule source is missing or invalid
+	RejectStatus int            `yaml:"RejectStatus"` // status code used when OnFailure is Reject
+	Claims       []Claim        `yaml:"Claims"`       // JWT claims to copy into headers
+	Key          string         `yaml:"Key"`          // secret key used to verify or compute signatures
+	KeyFile      string         `yaml:"KeyFile"`      // file holding the secret key, instead of Key
+	Mode         XFCCMode       `yaml:"Mode"`         // how XFCC handles an incoming X-Forwarded-Client-Cert header
+	Format       IDFormat       `yaml:"Format"`       // format of the IDs generated by RequestID
+	MaxLength    int            `yaml:"MaxLength"`    // maximum length of an incoming request ID
+	Algorithm    Algorithm      `yaml:"Algorithm"`    // signature algorithm
+	Path         string         `yaml:"Path"`         // request path the rule is restricted to
+	PathMatch    PathMatchType  `yaml:"PathMatch"`    // how Path is matched against the request path
+	Methods      []string       `yaml:"Methods"`      // request methods the rule is restricted to
+	Scheme       string         `yaml:"Scheme"`       // request scheme (http or https) the rule is restricted to
+	If           []Condition    `yaml:"If"`           // conditions that must all match for the rule to apply
+	Unless       []Condition    `yaml:"Unless"`       // conditions that must not match for the rule to apply
+	Status       []string       `yaml:"Status"`       // response status codes (200, 500-599, 4xx) the rule is restricted to
+	ClientCIDRs  []string       `yaml:"ClientCIDRs"`  // client IP ranges the rule is restricted to
+	When         string         `yaml:"When"`         // boolean expression that must be true for the rule to apply
+	// TimestampHeader is the header holding the signature timestamp.
+	TimestampHeader string `yaml:"TimestampHeader"`
+	// SignaturePrefix is the prefix of the signature in the Header of a Verify rule (sha256=).
+	SignaturePrefix string `yaml:"SignaturePrefix"`
+	// SignatureParam is the key of the signatures when the Header of a Verify rule
+	// is a list of key=value pairs (v1).
+	SignatureParam string `yaml:"SignatureParam"`
+	// Tolerance is how far the timestamp of a verified signature can be from now (5m).
+	Tolerance string `yaml:"Tolerance"`
+	// Label is the name of the signature in the Signature and Signature-Input headers.
+	Label string `yaml:"Label"`
+	// KeyID identifies the signing key for the upstream.
+	KeyID string `yaml:"KeyID"`
+	// Expires is how long a signature is valid (5m, 1h), it does not expire when empty.
+	Expires string `yaml:"Expires"`
+	// AccessKeyID is the AWS access key ID SigV4 signs with.
+	AccessKeyID string `yaml:"AccessKeyID"`
+	// Region and Service are the AWS region and service SigV4 signs for.
+	Region  string `yaml:"Region"`
+	Service string `yaml:"Service"`
+	// MaxBodySize is the maximum size in bytes of a request body read by the rule.
+	MaxBodySize int64 `yaml:"MaxBodySize"`
+	// TagHeader is the header describing the result of the rule when OnFailure is Tag.
+	TagHeader string `yaml:"TagHeader"`
+	// Filters transform the values written by the rule, in order (lower, trim, substring:0:8...).
+	Filters []string `yaml:"Filters"`
+	// From is the header the rule reads its source value from.
+	From string `yaml:"From"`
+	// Table is the lookup table of Map, the first matching entry being used.
+	Table []Mapping `yaml:"Table"`
+	// TableFile is a CSV or JSON file holding the lookup table, instead of Table.
+	TableFile string `yaml:"TableFile"`
+	// Match is how the keys of Table are matched against the source value.
+	Match MatchType `yaml:"Match"`
+	// if Interpolate is true, the Value of Add, Set and BasicAuth rules, the Values of Join rules and
+	// the ValueReplace of RewriteValueRule and BasicAuth rules can reference secrets, or be templates.
+	// They are written as is otherwise (default).
+	Interpolate bool `yaml:"Interpolate"`
+	// if UnsignedPayload is true, SigV4 does not sign the request body, which is not buffered.
+	UnsignedPayload bool `yaml:"UnsignedPayload"`
 	// if SetOnResponse is true, the header will be changed on the response. It will be on the request otherwise (default).
 	SetOnResponse bool `yaml:"SetOnResponse"`
 }
 
+// Condition tests the value of a header or of a query parameter.
+// Without Value nor Matches, it tests the presence of the header or parameter.
+type Condition struct {
+	Header  string `yaml:"Header"`  // header to test
+	Query   string `yaml:"Query"`   // query parameter to test, instead of a header
+	Value   string `yaml:"Value"`   // exact value the header must have
+	Matches string `yaml:"Matches"` // regexp the header value must match
+	Absent  bool   `yaml:"Absent"`  // if Absent is true, the header must not be present
+	// if Response is true, the response header is tested instead of the request one (response rules only).
+	Response bool `yaml:"Response"`
+}
+
+// Claim maps a JWT claim to the header it is copied into.
+type Claim struct {
+	Claim  string `yaml:"Claim"`  // claim name, nested claims being separated by dots (address.country)
+	Header string `yaml:"Header"` // header the claim value is written to
+}
+
+// Mapping is an entry of a Map table.
+type Mapping struct {
+	Key   string `yaml:"Key"`   // value to match, or regexp when Match is Regexp
+	Value string `yaml:"Value"` // value written on match, which can use the regexp groups ($1)
+}
+
 var ErrMissingRequiredFields = errors.New("missing required fields")
 
 var ErrInvalidRuleType = errors.New("invalid rule type")
 
 var ErrInvalidRegexp = errors.New("invalid regexp")
 
+var ErrInvalidCondition = errors.New("invalid condition")
+
+var ErrInvalidTemplate = errors.New("invalid template")
+
+var ErrMissingReference = errors.New("missing reference")
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+var ErrInvalidFailureAction = errors.New("invalid failure action")
+
+var ErrInvalidKey = errors.New("invalid key")
+
+var ErrUnknownField = errors.New("unknown field")
+
+var ErrInvalidMode = errors.New("invalid mode")
+
+var ErrInvalidFormat = errors.New("invalid format")
+
+var ErrInvalidAlgorithm = errors.New("invalid algorithm")
+
+var ErrInvalidTable = errors.New("invalid table")
+
+var ErrInvalidMatch = errors.New("invalid match type")
+
+var ErrInvalidDuration = errors.New("invalid duration")
+
+var ErrInvalidRejectStatus = errors.New("invalid reject status")
+
+var ErrRequestOnly = errors.New("rule type cannot be applied on the response")
+
+var ErrResponseOnly = errors.New("response headers can only be referenced by response rules")
+
 var ErrNotHTTPHijacker = errors.New("not an http.Hijacker")
 
 type Handler interface {
 	Validate() error
 	Handle(rw http.ResponseWriter, req *http.Request)
 }
+
+// ResponseHandler is implemented by request handlers that also act on the response
+// of the requests they handled, before the response rules are applied.
+type ResponseHandler interface {
+	HandleResponse(rw http.ResponseWriter, req *http.Request)
+}
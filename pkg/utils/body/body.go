@@ -0,0 +1,50 @@
+// Package body reads request bodies, leaving them intact for the backend.
+package body
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Read reads the body of req, which is replaced with a copy to be forwarded.
+// When maxSize is positive, at most maxSize bytes are read: the returned
+// boolean is false if the body is larger, in which case it is read partially
+// but still forwarded entirely.
+func Read(req *http.Request, maxSize int64) ([]byte, bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true, nil
+	}
+
+	reader := req.Body
+	if maxSize > 0 {
+		reader = io.NopCloser(io.LimitReader(req.Body, maxSize+1))
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, false, fmt.Errorf("read body: %w", err)
+	}
+
+	if maxSize > 0 && int64(len(content)) > maxSize {
+		req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(content), req.Body), Closer: req.Body}
+
+		return content[:maxSize], false, nil
+	}
+
+	_ = req.Body.Close()
+
+	req.Body = io.NopCloser(bytes.NewReader(content))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	return content, true, nil
+}
+
+// readCloser reads a partially read body, closing the original one.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,75 @@
+package body_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/utils/body"
+)
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		body             string
+		maxSize          int64
+		expectedContent  string
+		expectedComplete bool
+	}{
+		{
+			name:             "Read without limit",
+			body:             "hello world",
+			expectedContent:  "hello world",
+			expectedComplete: true,
+		},
+		{
+			name:             "Read within limit",
+			body:             "hello world",
+			maxSize:          11,
+			expectedContent:  "hello world",
+			expectedComplete: true,
+		},
+		{
+			name:             "Read over limit",
+			body:             "hello world",
+			maxSize:          5,
+			expectedContent:  "hello",
+			expectedComplete: false,
+		},
+		{
+			name:             "Read empty body",
+			expectedComplete: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var reqBody io.Reader
+			if test.body != "" {
+				reqBody = strings.NewReader(test.body)
+			}
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://example.com/", reqBody)
+			require.NoError(t, err)
+
+			content, complete, err := body.Read(req, test.maxSize)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedContent, string(content))
+			assert.Equal(t, test.expectedComplete, complete)
+
+			// The whole body is still forwarded.
+			if test.body != "" {
+				forwarded, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.Equal(t, test.body, string(forwarded))
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package clientip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrInvalidCIDR            = errors.New("invalid CIDR")
+	ErrInvalidForwardedHeader = errors.New("invalid forwarded header")
+)
+
+// Forwarding headers the trusted proxies can write.
+const (
+	XForwardedFor = "X-Forwarded-For"
+	Forwarded     = "Forwarded"
+)
+
+// Resolver finds the IP of the client that sent a request, trusting the
+// forwarding header only when it was set by a trusted proxy.
+type Resolver struct {
+	trustedProxies []*net.IPNet
+	// forwarded is set when the trusted proxies write the Forwarded header
+	// rather than X-Forwarded-For.
+	forwarded bool
+}
+
+// NewResolver returns a Resolver trusting the given proxy CIDRs (or IPs),
+// which write forwardedHeader (X-Forwarded-For when empty, or Forwarded).
+func NewResolver(trustedProxies []string, forwardedHeader string) (*Resolver, error) {
+	nets, err := ParseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := &Resolver{trustedProxies: nets}
+
+	switch {
+	case forwardedHeader == "" || strings.EqualFold(forwardedHeader, XForwardedFor):
+	case strings.EqualFold(forwardedHeader, Forwarded):
+		resolver.forwarded = true
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidForwardedHeader, forwardedHeader)
+	}
+
+	return resolver, nil
+}
+
+// ParseCIDRs parses a list of CIDRs, where a bare IP is a single host network.
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidCIDR, cidr)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCIDR, cidr)
+		}
+
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// Contains reports whether ip belongs to one of nets.
+func Contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP returns the IP of the client, or nil if it cannot be determined.
+// Starting from the peer address, the forwarding chain of the header written
+// by the trusted proxies is walked from the closest hop as long as hops are
+// trusted proxies, so that entries added by the client itself are ignored.
+// The other forwarding header is never read, as the client could set it.
+func (r *Resolver) ClientIP(req *http.Request) net.IP {
+	ip := parseIP(req.RemoteAddr)
+	if ip == nil || r == nil || len(r.trustedProxies) == 0 {
+		return ip
+	}
+
+	var hops []string
+	if r.forwarded {
+		hops = forwardedFor(req.Header)
+	} else {
+		hops = xForwardedFor(req.Header)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		if !Contains(r.trustedProxies, ip) {
+			return ip
+		}
+
+		ip = parseIP(hops[i])
+		if ip == nil {
+			return nil
+		}
+	}
+
+	return ip
+}
+
+// forwardedFor returns the forwarding chain of the Forwarded header, from the
+// original client to the closest proxy.
+func forwardedFor(header http.Header) []string {
+	var hops []string
+
+	for _, element := range strings.Split(strings.Join(header.Values(Forwarded), ","), ",") {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			if strings.EqualFold(key, "for") {
+				hops = append(hops, strings.Trim(value, `"`))
+			}
+		}
+	}
+
+	return hops
+}
+
+// xForwardedFor returns the forwarding chain of the X-Forwarded-For header,
+// from the original client to the closest proxy.
+func xForwardedFor(header http.Header) []string {
+	var hops []string
+
+	for _, value := range header.Values(XForwardedFor) {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	return hops
+}
+
+// parseIP parses an IP optionally followed by a port, IPv6 being possibly bracketed.
+func parseIP(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
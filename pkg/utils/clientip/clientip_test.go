@@ -0,0 +1,148 @@
+package clientip_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/utils/clientip"
+)
+
+func TestClientIP(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name            string
+		trustedProxies  []string
+		forwardedHeader string
+		remoteAddr      string
+		headers         map[string]string
+		expectedIP      string
+	}{
+		{
+			name:       "remote address",
+			remoteAddr: "203.0.113.7:1234",
+			expectedIP: "203.0.113.7",
+		},
+		{
+			name:       "forwarding headers ignored without trusted proxies",
+			remoteAddr: "203.0.113.7:1234",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.1"},
+			expectedIP: "203.0.113.7",
+		},
+		{
+			name:           "untrusted peer",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "203.0.113.7:1234",
+			headers:        map[string]string{"X-Forwarded-For": "192.168.1.1"},
+			expectedIP:     "203.0.113.7",
+		},
+		{
+			name:           "trusted peer",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:1234",
+			headers:        map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expectedIP:     "198.51.100.1",
+		},
+		{
+			name:           "spoofed entry",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:1234",
+			headers:        map[string]string{"X-Forwarded-For": "192.168.1.1, 198.51.100.1, 10.0.0.3"},
+			expectedIP:     "198.51.100.1",
+		},
+		{
+			name:           "only trusted hops",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:1234",
+			headers:        map[string]string{"X-Forwarded-For": "10.0.0.4, 10.0.0.3"},
+			expectedIP:     "10.0.0.4",
+		},
+		{
+			name:            "forwarded header",
+			trustedProxies:  []string{"10.0.0.2"},
+			forwardedHeader: "Forwarded",
+			remoteAddr:      "10.0.0.2:1234",
+			headers:         map[string]string{"Forwarded": `for=192.168.1.1, for="[2001:db8::17]:4711";proto=https`},
+			expectedIP:      "2001:db8::17",
+		},
+		{
+			name:            "unparsable hop",
+			trustedProxies:  []string{"10.0.0.0/8"},
+			forwardedHeader: "forwarded",
+			remoteAddr:      "10.0.0.2:1234",
+			headers:         map[string]string{"Forwarded": "for=unknown"},
+			expectedIP:      "<nil>",
+		},
+		{
+			name:           "spoofed forwarded header ignored",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:1234",
+			headers: map[string]string{
+				"Forwarded":       "for=192.168.1.10",
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			expectedIP: "198.51.100.1",
+		},
+		{
+			name:            "spoofed x-forwarded-for header ignored",
+			trustedProxies:  []string{"10.0.0.0/8"},
+			forwardedHeader: "Forwarded",
+			remoteAddr:      "10.0.0.2:1234",
+			headers: map[string]string{
+				"Forwarded":       "for=198.51.100.1",
+				"X-Forwarded-For": "192.168.1.10",
+			},
+			expectedIP: "198.51.100.1",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			resolver, err := clientip.NewResolver(test.trustedProxies, test.forwardedHeader)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+			req.RemoteAddr = test.remoteAddr
+
+			for hName, hVal := range test.headers {
+				req.Header.Set(hName, hVal)
+			}
+
+			assert.Equal(t, test.expectedIP, resolver.ClientIP(req).String())
+		})
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	t.Parallel()
+
+	nets, err := clientip.ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"})
+	require.NoError(t, err)
+
+	assert.Equal(t, true, clientip.Contains(nets, net.ParseIP("10.1.2.3")))
+	assert.Equal(t, true, clientip.Contains(nets, net.ParseIP("192.168.1.1")))
+	assert.Equal(t, false, clientip.Contains(nets, net.ParseIP("192.168.1.2")))
+	assert.Equal(t, true, clientip.Contains(nets, net.ParseIP("2001:db8::1")))
+
+	_, err = clientip.ParseCIDRs([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+
+	_, err = clientip.ParseCIDRs([]string{"not an ip"})
+	assert.Error(t, err)
+}
+
+func TestNewResolver(t *testing.T) {
+	t.Parallel()
+
+	_, err := clientip.NewResolver([]string{"10.0.0.0/8"}, "X-Real-IP")
+	assert.Error(t, err)
+
+	_, err = clientip.NewResolver([]string{"10.0.0.0/33"}, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package header
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+// OriginalPrefix is the namespace of the request headers as received by the
+// middleware, before any rule changed them (original:Host).
+const OriginalPrefix = "original:"
+
+// Get returns the first value of the request header, or false when it is missing.
+func Get(req *http.Request, header string) (string, bool) {
+	values := Values(req, header)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+// Values returns the values of the request header, Host being read from
+// req.Host. Headers prefixed with OriginalPrefix are read from the headers
+// saved before the rules were applied, or from the request if there are none.
+func Values(req *http.Request, header string) []string {
+	if name, ok := trimPrefixFold(header, OriginalPrefix); ok {
+		if original := types.GetState(req).Original(); original != nil {
+			return original.Values(name)
+		}
+
+		header = name
+	}
+
+	if strings.EqualFold(header, "Host") {
+		if req.Host == "" {
+			return nil
+		}
+
+		return []string{req.Host}
+	}
+
+	return req.Header.Values(header)
+}
+
+// MentionsOriginal reports whether s, such as a rule value, template or
+// expression, contains the OriginalPrefix namespace anywhere.
+func MentionsOriginal(s string) bool {
+	return strings.Contains(strings.ToLower(s), OriginalPrefix)
+}
+
+// IsOriginal reports whether header is in the OriginalPrefix namespace.
+func IsOriginal(header string) bool {
+	_, ok := trimPrefixFold(header, OriginalPrefix)
+
+	return ok
+}
+
+func trimPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+
+	return s[len(prefix):], true
+}
@@ -0,0 +1,141 @@
+package header_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		header        string
+		expectedValue string
+		expectedFound bool
+	}{
+		{
+			name:          "Get header",
+			header:        "Foo",
+			expectedValue: "Bar",
+			expectedFound: true,
+		},
+		{
+			name:          "Get first value of a repeated header",
+			header:        "Accept",
+			expectedValue: "text/html",
+			expectedFound: true,
+		},
+		{
+			name:          "Get Host header",
+			header:        "host",
+			expectedValue: "example.com",
+			expectedFound: true,
+		},
+		{
+			name:          "Get empty header",
+			header:        "Empty",
+			expectedValue: "",
+			expectedFound: true,
+		},
+		{
+			name:          "Get missing header",
+			header:        "Missing",
+			expectedValue: "",
+			expectedFound: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+			req.Header.Set("Foo", "Bar")
+			req.Header.Add("Accept", "text/html")
+			req.Header.Add("Accept", "application/json")
+			req.Header.Set("Empty", "")
+
+			value, found := header.Get(req, test.header)
+
+			assert.Equal(t, test.expectedValue, value)
+			assert.Equal(t, test.expectedFound, found)
+		})
+	}
+}
+
+func TestGetOriginal(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.Header.Set("Foo", "Bar")
+
+	// Without saved headers, the request ones are used.
+	value, found := header.Get(req, "original:Foo")
+	assert.Equal(t, "Bar", value)
+	assert.Equal(t, true, found)
+
+	state := &types.State{}
+	state.SaveOriginal(req)
+	req = types.WithState(req, state)
+
+	req.Host = "backend.internal"
+	req.Header.Set("Foo", "Baz")
+	req.Header.Set("Added", "true")
+
+	tests := []struct {
+		name          string
+		header        string
+		expectedValue string
+		expectedFound bool
+	}{
+		{
+			name:          "Get original header",
+			header:        "original:Foo",
+			expectedValue: "Bar",
+			expectedFound: true,
+		},
+		{
+			name:          "Get original Host",
+			header:        "Original:host",
+			expectedValue: "example.com",
+			expectedFound: true,
+		},
+		{
+			name:          "Get original header added by a rule",
+			header:        "original:Added",
+			expectedValue: "",
+			expectedFound: false,
+		},
+		{
+			name:          "Get current header",
+			header:        "Foo",
+			expectedValue: "Baz",
+			expectedFound: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			value, found := header.Get(req, test.header)
+
+			assert.Equal(t, test.expectedValue, value)
+			assert.Equal(t, test.expectedFound, found)
+		})
+	}
+}
+
+func TestMentionsOriginal(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, true, header.MentionsOriginal("^original:Host"))
+	assert.Equal(t, true, header.MentionsOriginal(`{{ header "Original:X-Tenant" }}`))
+	assert.Equal(t, false, header.MentionsOriginal("^req:Host"))
+}
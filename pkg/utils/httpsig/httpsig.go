@@ -0,0 +1,153 @@
+// Package httpsig builds the signature base of HTTP Message Signatures, as
+// defined by RFC 9421.
+package httpsig
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var ErrMissingComponent = errors.New("missing component")
+
+// derived maps the supported derived components to their value.
+var derived = map[string]func(req *http.Request) string{
+	"@method":    func(req *http.Request) string { return req.Method },
+	"@authority": authority,
+	"@scheme":    scheme,
+	"@target-uri": func(req *http.Request) string {
+		return scheme(req) + "://" + authority(req) + requestTarget(req)
+	},
+	"@request-target": requestTarget,
+	"@path":           path,
+	"@query":          func(req *http.Request) string { return "?" + req.URL.RawQuery },
+}
+
+// Params are the signature parameters.
+type Params struct {
+	// Created is the Unix time of the signature creation.
+	Created int64
+	// Expires is the Unix time of the signature expiration, 0 if it does not expire.
+	Expires int64
+	// KeyID identifies the key, omitted when empty.
+	KeyID string
+}
+
+// ValidComponent reports whether name is a supported derived component or a
+// lower case header field name.
+func ValidComponent(name string) bool {
+	if strings.HasPrefix(name, "@") {
+		_, ok := derived[name]
+
+		return ok
+	}
+
+	return name != "" && name == strings.ToLower(name) && !strings.ContainsAny(name, " \t\":;()")
+}
+
+// Input returns the value of the signature parameters: the list of the covered
+// components followed by the parameters, as written in Signature-Input.
+func Input(components []string, params Params) string {
+	quoted := make([]string, 0, len(components))
+	for _, component := range components {
+		quoted = append(quoted, quote(component))
+	}
+
+	input := "(" + strings.Join(quoted, " ") + ");created=" + strconv.FormatInt(params.Created, 10)
+
+	if params.Expires != 0 {
+		input += ";expires=" + strconv.FormatInt(params.Expires, 10)
+	}
+
+	if params.KeyID != "" {
+		input += ";keyid=" + quote(params.KeyID)
+	}
+
+	return input
+}
+
+// Base returns the signature base of req, which is what is signed, for the
+// covered components and the signature parameters input.
+func Base(req *http.Request, components []string, input string) (string, error) {
+	var base strings.Builder
+
+	for _, component := range components {
+		value, err := componentValue(req, component)
+		if err != nil {
+			return "", err
+		}
+
+		base.WriteString(quote(component) + ": " + value + "\n")
+	}
+
+	base.WriteString(`"@signature-params": ` + input)
+
+	return base.String(), nil
+}
+
+// quote returns s as a structured field string (RFC 8941).
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func componentValue(req *http.Request, component string) (string, error) {
+	if value, ok := derived[component]; ok {
+		return value(req), nil
+	}
+
+	if component == "host" {
+		return authority(req), nil
+	}
+
+	values := req.Header.Values(component)
+	if len(values) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrMissingComponent, component)
+	}
+
+	trimmed := make([]string, 0, len(values))
+	for _, value := range values {
+		trimmed = append(trimmed, strings.TrimSpace(value))
+	}
+
+	return strings.Join(trimmed, ", "), nil
+}
+
+// authority returns the lower case host of req, without the default port of its scheme.
+func authority(req *http.Request) string {
+	host := strings.ToLower(req.Host)
+	if host == "" {
+		host = strings.ToLower(req.URL.Host)
+	}
+
+	if scheme(req) == "https" {
+		return strings.TrimSuffix(host, ":443")
+	}
+
+	return strings.TrimSuffix(host, ":80")
+}
+
+func scheme(req *http.Request) string {
+	if req.TLS != nil || strings.EqualFold(req.URL.Scheme, "https") {
+		return "https"
+	}
+
+	return "http"
+}
+
+func path(req *http.Request) string {
+	if escaped := req.URL.EscapedPath(); escaped != "" {
+		return escaped
+	}
+
+	return "/"
+}
+
+func requestTarget(req *http.Request) string {
+	if req.URL.RawQuery == "" {
+		return path(req)
+	}
+
+	return path(req) + "?" + req.URL.RawQuery
+}
@@ -0,0 +1,155 @@
+package httpsig_test
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/utils/httpsig"
+)
+
+// testRequest returns the test request of RFC 9421 Appendix B.2.
+func testRequest(t *testing.T) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "http://example.com/foo?param=Value&Pet=dog",
+		strings.NewReader(`{"hello": "world"}`))
+	require.NoError(t, err)
+
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Digest", "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:")
+	req.Header.Set("Content-Length", "18")
+
+	return req
+}
+
+// TestHMACVector checks the signature of RFC 9421 Appendix B.2.5.
+func TestHMACVector(t *testing.T) {
+	t.Parallel()
+
+	components := []string{"date", "@authority", "content-type"}
+	input := httpsig.Input(components, httpsig.Params{Created: 1618884473, KeyID: "test-shared-secret"})
+	assert.Equal(t, `("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`, input)
+
+	base, err := httpsig.Base(testRequest(t), components, input)
+	require.NoError(t, err)
+	assert.Equal(t, `"date": Tue, 20 Apr 2021 02:07:55 GMT
+"@authority": example.com
+"content-type": application/json
+"@signature-params": ("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`, base)
+
+	key, err := base64.StdEncoding.DecodeString("uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ==")
+	require.NoError(t, err)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(base))
+	assert.Equal(t, "pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// TestEd25519Vector checks the signature of RFC 9421 Appendix B.2.6.
+func TestEd25519Vector(t *testing.T) {
+	t.Parallel()
+
+	components := []string{"date", "@method", "@path", "@authority", "content-type", "content-length"}
+	input := httpsig.Input(components, httpsig.Params{Created: 1618884473, KeyID: "test-key-ed25519"})
+
+	base, err := httpsig.Base(testRequest(t), components, input)
+	require.NoError(t, err)
+	assert.Equal(t, `"date": Tue, 20 Apr 2021 02:07:55 GMT
+"@method": POST
+"@path": /foo
+"@authority": example.com
+"content-type": application/json
+"content-length": 18
+"@signature-params": ("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`, base)
+
+	// test-key-ed25519 of RFC 9421 Appendix B.1.4.
+	der, err := base64.StdEncoding.DecodeString("MC4CAQAwBQYDK2VwBCIEIJ+DYvh6SEqVTm50DFtMDoQikTmiCqirVv9mWG9qfSnF")
+	require.NoError(t, err)
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	require.NoError(t, err)
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		t.Fatalf("unexpected key %T", key)
+	}
+
+	assert.Equal(t,
+		"wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw==",
+		base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(base))))
+}
+
+// TestDerivedComponents checks the examples of RFC 9421 Section 2.2.
+func TestDerivedComponents(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		url       string
+		component string
+		expected  string
+	}{
+		{name: "method", url: "https://www.example.com/path?param=value", component: "@method", expected: "POST"},
+		{name: "target URI", url: "https://www.example.com/path?param=value", component: "@target-uri", expected: "https://www.example.com/path?param=value"},
+		{name: "authority", url: "https://WWW.example.com:443/path", component: "@authority", expected: "www.example.com"},
+		{name: "authority with port", url: "http://www.example.com:8080/path", component: "@authority", expected: "www.example.com:8080"},
+		{name: "scheme", url: "http://www.example.com/path", component: "@scheme", expected: "http"},
+		{name: "request target", url: "https://www.example.com/path?param=value", component: "@request-target", expected: "/path?param=value"},
+		{name: "path", url: "https://www.example.com/path?param=value", component: "@path", expected: "/path"},
+		{name: "empty path", url: "https://www.example.com", component: "@path", expected: "/"},
+		{name: "query", url: "https://www.example.com/path?param=value&foo=bar&baz=bat%2Dman", component: "@query", expected: "?param=value&foo=bar&baz=bat%2Dman"},
+		{name: "empty query", url: "https://www.example.com/path", component: "@query", expected: "?"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, test.url, nil)
+			require.NoError(t, err)
+
+			base, err := httpsig.Base(req, []string{test.component}, "()")
+			require.NoError(t, err)
+			assert.Equal(t, `"`+test.component+`": `+test.expected+"\n"+`"@signature-params": ()`, base)
+		})
+	}
+}
+
+func TestHeaderComponents(t *testing.T) {
+	t.Parallel()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://example.com/", nil)
+	require.NoError(t, err)
+
+	req.Header.Add("Cache-Control", "max-age=60  ")
+	req.Header.Add("Cache-Control", "   must-revalidate")
+
+	base, err := httpsig.Base(req, []string{"cache-control"}, "()")
+	require.NoError(t, err)
+	assert.Equal(t, "\"cache-control\": max-age=60, must-revalidate\n\"@signature-params\": ()", base)
+
+	_, err = httpsig.Base(req, []string{"x-missing"}, "()")
+	assert.Equal(t, true, errors.Is(err, httpsig.ErrMissingComponent))
+}
+
+func TestInput(t *testing.T) {
+	t.Parallel()
+
+	input := httpsig.Input([]string{"@method"}, httpsig.Params{Created: 1, Expires: 301, KeyID: `key "1"`})
+	assert.Equal(t, `("@method");created=1;expires=301;keyid="key \"1\""`, input)
+
+	assert.Equal(t, true, httpsig.ValidComponent("@authority"))
+	assert.Equal(t, true, httpsig.ValidComponent("content-digest"))
+	assert.Equal(t, false, httpsig.ValidComponent("@status"))
+	assert.Equal(t, false, httpsig.ValidComponent("Content-Type"))
+}
@@ -0,0 +1,84 @@
+// Package id generates unique identifiers: random UUIDs (version 4), time ordered
+// UUIDs (version 7) and ULIDs.
+package id
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+)
+
+// crockford is the Crockford's base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// UUIDv4 returns a random UUID, as defined by RFC 9562.
+func UUIDv4(random io.Reader) (string, error) {
+	var uuid [16]byte
+	if _, err := io.ReadFull(random, uuid[:]); err != nil {
+		return "", fmt.Errorf("read random: %w", err)
+	}
+
+	return formatUUID(uuid, 4), nil
+}
+
+// UUIDv7 returns a UUID starting with the Unix timestamp of now in milliseconds,
+// as defined by RFC 9562.
+func UUIDv7(now time.Time, random io.Reader) (string, error) {
+	var uuid [16]byte
+	if _, err := io.ReadFull(random, uuid[6:]); err != nil {
+		return "", fmt.Errorf("read random: %w", err)
+	}
+
+	putMillis(uuid[:6], now)
+
+	return formatUUID(uuid, 7), nil
+}
+
+// formatUUID sets the version and variant bits of uuid and returns its textual form.
+func formatUUID(uuid [16]byte, version byte) string {
+	uuid[6] = uuid[6]&0x0f | version<<4
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	encoded := hex.EncodeToString(uuid[:])
+
+	return encoded[:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:]
+}
+
+// ULID returns a ULID starting with the Unix timestamp of now in milliseconds,
+// see https://github.com/ulid/spec.
+func ULID(now time.Time, random io.Reader) (string, error) {
+	var ulid [16]byte
+	if _, err := io.ReadFull(random, ulid[6:]); err != nil {
+		return "", fmt.Errorf("read random: %w", err)
+	}
+
+	putMillis(ulid[:6], now)
+
+	// 26 characters of 5 bits encode the 128 bits, the first one holding only 3 bits.
+	encoded := make([]byte, 26)
+
+	for i := range encoded {
+		bit := 128 - 5*(26-i)
+
+		var chunk int
+		for b := bit; b < bit+5; b++ {
+			chunk <<= 1
+			if b >= 0 && ulid[b/8]&(0x80>>(b%8)) != 0 {
+				chunk |= 1
+			}
+		}
+
+		encoded[i] = crockford[chunk]
+	}
+
+	return string(encoded), nil
+}
+
+// putMillis writes the Unix timestamp of now in milliseconds on the 6 bytes of dst.
+func putMillis(dst []byte, now time.Time) {
+	var millis [8]byte
+	binary.BigEndian.PutUint64(millis[:], uint64(now.UnixMilli()))
+	copy(dst, millis[2:])
+}
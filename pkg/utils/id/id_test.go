@@ -0,0 +1,85 @@
+package id_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/utils/id"
+)
+
+var errReader = errors.New("no randomness")
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errReader
+}
+
+func TestUUIDv4(t *testing.T) {
+	t.Parallel()
+
+	uuid, err := id.UUIDv4(bytes.NewReader(bytes.Repeat([]byte{0xff}, 16)))
+	require.NoError(t, err)
+	assert.Equal(t, "ffffffff-ffff-4fff-bfff-ffffffffffff", uuid)
+
+	uuid, err = id.UUIDv4(rand.Reader)
+	require.NoError(t, err)
+	assert.Equal(t, true, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid))
+
+	_, err = id.UUIDv4(failingReader{})
+	assert.Error(t, err)
+}
+
+func TestUUIDv7(t *testing.T) {
+	t.Parallel()
+
+	// Timestamp of the RFC 9562 example.
+	now := time.UnixMilli(0x017F22E279B0)
+
+	uuid, err := id.UUIDv7(now, bytes.NewReader(make([]byte, 10)))
+	require.NoError(t, err)
+	assert.Equal(t, "017f22e2-79b0-7000-8000-000000000000", uuid)
+
+	_, err = id.UUIDv7(now, failingReader{})
+	assert.Error(t, err)
+}
+
+func TestULID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		millis   int64
+		random   []byte
+		expected string
+	}{
+		{
+			name:     "ULID of the spec timestamp",
+			millis:   1469922850259,
+			random:   make([]byte, 10),
+			expected: "01ARZ3NDEK0000000000000000",
+		},
+		{
+			name:     "Maximum ULID",
+			millis:   1<<48 - 1,
+			random:   bytes.Repeat([]byte{0xff}, 10),
+			expected: "7ZZZZZZZZZZZZZZZZZZZZZZZZZ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ulid, err := id.ULID(time.UnixMilli(test.millis), bytes.NewReader(test.random))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, ulid)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package key
+
+import (
+	"fmt"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/value"
+)
+
+// Key is the secret key of a rule. A key file is read again whenever it changes.
+type Key struct {
+	value value.Value
+}
+
+// New returns the key of a rule, read from KeyFile when it is set. Key can hold
+// ${env:NAME} and ${file:PATH} references, see the value package.
+// Surrounding whitespace of a key file, such as a trailing newline, is ignored.
+func New(rule types.Rule) (*Key, error) {
+	if rule.KeyFile == "" {
+		keyValue, err := value.NewReferences(rule.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", types.ErrInvalidKey, err)
+		}
+
+		return &Key{value: keyValue}, nil
+	}
+
+	if rule.Key != "" {
+		return nil, fmt.Errorf("%w: Key and KeyFile cannot be both set", types.ErrInvalidKey)
+	}
+
+	keyValue, err := value.NewFile(rule.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidKey, err)
+	}
+
+	return &Key{value: keyValue}, nil
+}
+
+// Bytes returns the current key.
+func (k *Key) Bytes() []byte {
+	key, _ := k.value.Resolve(nil, nil)
+
+	return []byte(key)
+}
@@ -0,0 +1,93 @@
+package key_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/key"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("file-secret\n"), 0o600))
+
+	tests := []struct {
+		name     string
+		rule     types.Rule
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "inline key",
+			rule:     types.Rule{Key: "secret"},
+			expected: "secret",
+		},
+		{
+			name:     "key file",
+			rule:     types.Rule{KeyFile: keyFile},
+			expected: "file-secret",
+		},
+		{
+			name:    "missing key file",
+			rule:    types.Rule{KeyFile: filepath.Join(t.TempDir(), "missing")},
+			wantErr: true,
+		},
+		{
+			name:     "key file reference",
+			rule:     types.Rule{Key: "${file:" + keyFile + "}"},
+			expected: "file-secret",
+		},
+		{
+			name:    "missing key reference",
+			rule:    types.Rule{Key: "${env:HTRANSFORMATION_TEST_MISSING}"},
+			wantErr: true,
+		},
+		{
+			name:    "both key and key file",
+			rule:    types.Rule{Key: "secret", KeyFile: keyFile},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			loaded, err := key.New(test.rule)
+			if test.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(loaded.Bytes()))
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	t.Parallel()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("first\n"), 0o600))
+
+	loaded, err := key.New(types.Rule{KeyFile: keyFile})
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(loaded.Bytes()))
+
+	// Files are checked for changes once per second.
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.WriteFile(keyFile, []byte("second\n"), 0o600))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+	time.Sleep(1100 * time.Millisecond)
+
+	assert.Equal(t, "second", string(loaded.Bytes()))
+}
@@ -0,0 +1,41 @@
+package query
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Remove removes every occurrence of param from rawQuery, keeping the other parameters as is.
+func Remove(rawQuery, param string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+	kept := pairs[:0]
+
+	for _, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if key != param {
+			kept = append(kept, pair)
+		}
+	}
+
+	return strings.Join(kept, "&")
+}
+
+// Set replaces every occurrence of param in rawQuery with a single param=value pair.
+func Set(rawQuery, param, value string) string {
+	pair := url.QueryEscape(param) + "=" + url.QueryEscape(value)
+
+	rawQuery = Remove(rawQuery, param)
+	if rawQuery == "" {
+		return pair
+	}
+
+	return rawQuery + "&" + pair
+}
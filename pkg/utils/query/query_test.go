@@ -0,0 +1,106 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/utils/query"
+)
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		rawQuery string
+		param    string
+		expected string
+	}{
+		{
+			name:     "Remove parameter",
+			rawQuery: "a=1&tenant=acme&b=2",
+			param:    "tenant",
+			expected: "a=1&b=2",
+		},
+		{
+			name:     "Remove repeated parameter",
+			rawQuery: "tenant=acme&a=1&tenant=other",
+			param:    "tenant",
+			expected: "a=1",
+		},
+		{
+			name:     "Remove escaped parameter",
+			rawQuery: "a%20b=1&c=2",
+			param:    "a b",
+			expected: "c=2",
+		},
+		{
+			name:     "Remove parameter without value",
+			rawQuery: "debug&a=1",
+			param:    "debug",
+			expected: "a=1",
+		},
+		{
+			name:     "Remove missing parameter",
+			rawQuery: "b=2&a=1",
+			param:    "tenant",
+			expected: "b=2&a=1",
+		},
+		{
+			name:     "Remove from empty query",
+			rawQuery: "",
+			param:    "tenant",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, query.Remove(test.rawQuery, test.param))
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		rawQuery string
+		param    string
+		value    string
+		expected string
+	}{
+		{
+			name:     "Set parameter",
+			rawQuery: "a=1",
+			param:    "tenant",
+			value:    "acme",
+			expected: "a=1&tenant=acme",
+		},
+		{
+			name:     "Set existing parameter",
+			rawQuery: "tenant=old&a=1",
+			param:    "tenant",
+			value:    "acme",
+			expected: "a=1&tenant=acme",
+		},
+		{
+			name:     "Set escaped value",
+			rawQuery: "",
+			param:    "q",
+			value:    "a b&c",
+			expected: "q=a+b%26c",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, query.Set(test.rawQuery, test.param, test.value))
+		})
+	}
+}
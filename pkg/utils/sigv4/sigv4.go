@@ -0,0 +1,215 @@
+// Package sigv4 signs requests with AWS Signature Version 4, see
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html.
+package sigv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm = "AWS4-HMAC-SHA256"
+	// UnsignedPayload is the payload hash of requests whose body is not signed.
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// timeFormat is the format of X-Amz-Date.
+	timeFormat = "20060102T150405Z"
+)
+
+// Credentials are the static credentials requests are signed with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// HashPayload returns the hexadecimal SHA-256 of payload.
+func HashPayload(payload []byte) string {
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign sets the X-Amz-Date and Authorization headers of req. The host, the
+// Content-Type, Content-MD5 and X-Amz-* headers are signed. payloadHash is the
+// HashPayload of the body, or UnsignedPayload.
+func Sign(req *http.Request, credentials Credentials, region, service, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(timeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	canonicalHeaders, signedHeaders := headers(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL, service),
+		canonicalQuery(req.URL.RawQuery),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := amzDate[:8] + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := algorithm + "\n" + amzDate + "\n" + scope + "\n" + HashPayload([]byte(canonicalRequest))
+
+	signingKey := []byte("AWS4" + credentials.SecretAccessKey)
+	for _, part := range []string{amzDate[:8], region, service, "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+
+	req.Header.Set("Authorization", algorithm+
+		" Credential="+credentials.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+hex.EncodeToString(hmacSHA256(signingKey, stringToSign)))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+// headers returns the canonical headers, each followed by a new line, and the signed header names.
+func headers(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values := map[string]string{"host": strings.ToLower(host)}
+
+	for name, headerValues := range req.Header {
+		lower := strings.ToLower(name)
+		if !strings.HasPrefix(lower, "x-amz-") && lower != "content-type" && lower != "content-md5" {
+			continue
+		}
+
+		trimmed := make([]string, 0, len(headerValues))
+		for _, value := range headerValues {
+			// Sequential spaces are converted to a single space.
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+
+		values[lower] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// canonicalURI returns the path with each segment URI-encoded once for S3.
+// For the other services, the path is normalized and its segments are
+// URI-encoded twice.
+func canonicalURI(u *url.URL, service string) string {
+	if u.Path == "" {
+		return "/"
+	}
+
+	uriPath := u.Path
+	if service != "s3" {
+		uriPath = normalize(uriPath)
+	}
+
+	segments := strings.Split(uriPath, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+		if service != "s3" {
+			segments[i] = escape(segments[i])
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// normalize removes the empty, . and .. segments of an absolute path, keeping
+// its trailing slash.
+func normalize(uriPath string) string {
+	cleaned := path.Clean("/" + uriPath)
+	if strings.HasSuffix(uriPath, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned
+}
+
+// canonicalQuery returns the URI-encoded query parameters sorted by name then value.
+func canonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	var params [][2]string
+
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(param, "=")
+
+		// Parameters are decoded first, so that they are not encoded twice.
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+
+		if decoded, err := url.QueryUnescape(value); err == nil {
+			value = decoded
+		}
+
+		params = append(params, [2]string{escape(name), escape(value)})
+	}
+
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+
+		return params[i][1] < params[j][1]
+	})
+
+	encoded := make([]string, 0, len(params))
+	for _, param := range params {
+		encoded = append(encoded, param[0]+"="+param[1])
+	}
+
+	return strings.Join(encoded, "&")
+}
+
+// escape URI-encodes every byte of s but the unreserved characters A-Z, a-z, 0-9, -, _, . and ~.
+func escape(s string) string {
+	const upperhex = "0123456789ABCDEF"
+
+	var escaped strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		char := s[i]
+		if 'A' <= char && char <= 'Z' || 'a' <= char && char <= 'z' || '0' <= char && char <= '9' ||
+			char == '-' || char == '_' || char == '.' || char == '~' {
+			escaped.WriteByte(char)
+
+			continue
+		}
+
+		escaped.WriteByte('%')
+		escaped.WriteByte(upperhex[char>>4])
+		escaped.WriteByte(upperhex[char&15])
+	}
+
+	return escaped.String()
+}
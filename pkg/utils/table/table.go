@@ -0,0 +1,125 @@
+// Package table parses the lookup tables of rules from CSV or JSON files.
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+// Parse parses the content of the table file at path, according to its extension.
+//
+// A .csv file holds a key and a value per line, lines starting with # being
+// ignored. A .json file holds either an object mapping keys to values, or an
+// array of {"Key": ..., "Value": ...} objects. The entries are kept in order.
+//
+// A table without entries is an error, as the file is likely being rewritten.
+func Parse(path string, content []byte) ([]types.Mapping, error) {
+	var (
+		entries []types.Mapping
+		err     error
+	)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		entries, err = parseCSV(content)
+	case ".json":
+		entries, err = parseJSON(content)
+	default:
+		return nil, fmt.Errorf("%w: %s: unsupported format, expected .csv or .json", types.ErrInvalidTable, path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s: no entries", types.ErrInvalidTable, path)
+	}
+
+	return entries, nil
+}
+
+func parseCSV(content []byte) ([]types.Mapping, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidTable, err)
+	}
+
+	entries := make([]types.Mapping, 0, len(records))
+	for _, record := range records {
+		entries = append(entries, types.Mapping{Key: record[0], Value: record[1]})
+	}
+
+	return entries, nil
+}
+
+func parseJSON(content []byte) ([]types.Mapping, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidTable, err)
+	}
+
+	switch token {
+	case json.Delim('['):
+		var entries []types.Mapping
+
+		// The array is decoded again as a whole, Mapping fields matching JSON keys case-insensitively.
+		if err := json.Unmarshal(content, &entries); err != nil {
+			return nil, fmt.Errorf("%w: %w", types.ErrInvalidTable, err)
+		}
+
+		return entries, nil
+	case json.Delim('{'):
+		return parseJSONObject(decoder)
+	default:
+		return nil, fmt.Errorf("%w: expected a JSON object or array", types.ErrInvalidTable)
+	}
+}
+
+// parseJSONObject reads in order the members of an object, whose opening brace
+// has been read.
+func parseJSONObject(decoder *json.Decoder) ([]types.Mapping, error) {
+	var entries []types.Mapping
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", types.ErrInvalidTable, err)
+		}
+
+		key, _ := token.(string) // object keys are always strings.
+
+		var value string
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", types.ErrInvalidTable, key, err)
+		}
+
+		entries = append(entries, types.Mapping{Key: key, Value: value})
+	}
+
+	// The closing brace must end the content.
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrInvalidTable, err)
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: unexpected content after the object", types.ErrInvalidTable)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,119 @@
+package table_test
+
+import (
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/table"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected []types.Mapping
+		wantErr  bool
+	}{
+		{
+			name:    "CSV",
+			path:    "keys.csv",
+			content: "# key,consumer\nk-123,acme\n\"k,456\", globex\n",
+			expected: []types.Mapping{
+				{Key: "k-123", Value: "acme"},
+				{Key: "k,456", Value: "globex"},
+			},
+		},
+		{
+			name:    "CSV with a missing value",
+			path:    "keys.csv",
+			content: "k-123,acme\nk-456\n",
+			wantErr: true,
+		},
+		{
+			name:    "JSON object in order",
+			path:    "keys.JSON",
+			content: `{"k-456": "globex", "k-123": "acme"}`,
+			expected: []types.Mapping{
+				{Key: "k-456", Value: "globex"},
+				{Key: "k-123", Value: "acme"},
+			},
+		},
+		{
+			name:    "JSON array",
+			path:    "keys.json",
+			content: `[{"Key": "^k-1", "Value": "acme"}, {"key": "^k-", "value": "globex"}]`,
+			expected: []types.Mapping{
+				{Key: "^k-1", Value: "acme"},
+				{Key: "^k-", Value: "globex"},
+			},
+		},
+		{
+			name:    "empty CSV",
+			path:    "keys.csv",
+			content: "# key,consumer\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty JSON object",
+			path:    "keys.json",
+			content: `{}`,
+			wantErr: true,
+		},
+		{
+			name:    "empty JSON array",
+			path:    "keys.json",
+			content: `[]`,
+			wantErr: true,
+		},
+		{
+			name:    "JSON object with a non string value",
+			path:    "keys.json",
+			content: `{"k-123": 123}`,
+			wantErr: true,
+		},
+		{
+			name:    "truncated JSON object",
+			path:    "keys.json",
+			content: `{"k-123": "acme"`,
+			wantErr: true,
+		},
+		{
+			name:    "JSON content after the object",
+			path:    "keys.json",
+			content: `{"k-123": "acme"} {}`,
+			wantErr: true,
+		},
+		{
+			name:    "JSON string",
+			path:    "keys.json",
+			content: `"k-123"`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported format",
+			path:    "keys.yaml",
+			content: "k-123: acme",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			entries, err := table.Parse(test.path, []byte(test.content))
+			if test.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, entries)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Package watch reloads files when their modification time changes, so that
+// mounted secrets or tables can be rotated without restarting Traefik.
+package watch
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// File is the parsed content of a file, parsed again when the file changes.
+type File struct {
+	path     string
+	interval time.Duration
+	parse    func(content []byte) (interface{}, error)
+
+	mu      sync.Mutex
+	checked time.Time
+	modTime time.Time
+	value   interface{}
+}
+
+// New reads and parses the file at path, which is checked for changes at most
+// once per interval.
+func New(path string, interval time.Duration, parse func(content []byte) (interface{}, error)) (*File, error) {
+	file := &File{path: path, interval: interval, parse: parse}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("watch %s: %w", path, err)
+	}
+
+	if err := file.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+
+	file.checked = time.Now()
+
+	return file, nil
+}
+
+// Value returns the parsed content of the file. When the file has changed but
+// cannot be read or parsed anymore, the last parsed content is kept.
+func (f *File) Value() interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now := time.Now(); now.Sub(f.checked) >= f.interval {
+		f.checked = now
+
+		if info, err := os.Stat(f.path); err == nil && !info.ModTime().Equal(f.modTime) {
+			_ = f.load(info.ModTime())
+		}
+	}
+
+	return f.value
+}
+
+// load reads and parses the file, which was modified at modTime.
+func (f *File) load(modTime time.Time) error {
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("watch %s: %w", f.path, err)
+	}
+
+	value, err := f.parse(content)
+	if err != nil {
+		return fmt.Errorf("watch %s: %w", f.path, err)
+	}
+
+	f.modTime = modTime
+	f.value = value
+
+	return nil
+}
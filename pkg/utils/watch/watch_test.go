@@ -0,0 +1,90 @@
+package watch_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/utils/watch"
+)
+
+var errEmpty = errors.New("empty file")
+
+func parse(content []byte) (interface{}, error) {
+	trimmed := strings.TrimSpace(string(content))
+	if trimmed == "" {
+		return nil, errEmpty
+	}
+
+	return trimmed, nil
+}
+
+// write writes content to path, with a modification time distinct from the previous one.
+func write(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "secret")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	write(t, path, "first\n", start)
+
+	file, err := watch.New(path, 0, parse)
+	require.NoError(t, err)
+	assert.Equal(t, "first", file.Value())
+
+	write(t, path, "second\n", start.Add(time.Minute))
+	assert.Equal(t, "second", file.Value())
+
+	// An invalid content keeps the last parsed one.
+	write(t, path, "\n", start.Add(2*time.Minute))
+	assert.Equal(t, "second", file.Value())
+
+	// A removed file keeps the last parsed content.
+	require.NoError(t, os.Remove(path))
+	assert.Equal(t, "second", file.Value())
+
+	write(t, path, "third", start.Add(3*time.Minute))
+	assert.Equal(t, "third", file.Value())
+}
+
+func TestFileInterval(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "secret")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	write(t, path, "first", start)
+
+	file, err := watch.New(path, time.Hour, parse)
+	require.NoError(t, err)
+
+	write(t, path, "second", start.Add(time.Minute))
+	assert.Equal(t, "first", file.Value())
+}
+
+func TestNewErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	_, err := watch.New(filepath.Join(dir, "missing"), 0, parse)
+	assert.Error(t, err)
+
+	path := filepath.Join(dir, "empty")
+	write(t, path, "", time.Now())
+
+	_, err = watch.New(path, 0, parse)
+	assert.Error(t, err)
+}
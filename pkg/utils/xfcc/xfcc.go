@@ -0,0 +1,134 @@
+// Package xfcc formats and parses the Envoy X-Forwarded-Client-Cert header:
+// comma separated elements, each made of semicolon separated key=value pairs.
+//
+//	By=spiffe://gw;Hash=1f2e...;Subject="CN=client,O=Acme";URI=spiffe://client
+package xfcc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Header is the name of the header.
+const Header = "X-Forwarded-Client-Cert"
+
+var ErrInvalidXFCC = errors.New("invalid X-Forwarded-Client-Cert")
+
+// Pair is a key=value pair of an element.
+type Pair struct {
+	Key   string
+	Value string
+}
+
+// Element describes one client certificate.
+type Element []Pair
+
+// Values returns the values of key in the element.
+func (e Element) Values(key string) []string {
+	var values []string
+
+	for _, pair := range e {
+		if strings.EqualFold(pair.Key, key) {
+			values = append(values, pair.Value)
+		}
+	}
+
+	return values
+}
+
+// Format returns the header value of elements.
+func Format(elements []Element) string {
+	formatted := make([]string, 0, len(elements))
+
+	for _, element := range elements {
+		pairs := make([]string, 0, len(element))
+		for _, pair := range element {
+			pairs = append(pairs, pair.Key+"="+quote(pair.Value))
+		}
+
+		formatted = append(formatted, strings.Join(pairs, ";"))
+	}
+
+	return strings.Join(formatted, ",")
+}
+
+// quote quotes value when it holds a separator, escaping its quotes and backslashes.
+func quote(value string) string {
+	if !strings.ContainsAny(value, `,;=" `) {
+		return value
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// Parse parses a header value into its elements.
+func Parse(header string) ([]Element, error) {
+	var (
+		elements []Element
+		element  Element
+		token    strings.Builder
+		key      string
+		hasKey   bool
+		quoted   bool
+	)
+
+	endPair := func() error {
+		if !hasKey {
+			if strings.TrimSpace(token.String()) == "" {
+				return nil
+			}
+
+			return fmt.Errorf("%w: missing '=' in %q", ErrInvalidXFCC, token.String())
+		}
+
+		element = append(element, Pair{Key: key, Value: token.String()})
+		token.Reset()
+
+		key, hasKey = "", false
+
+		return nil
+	}
+
+	for i := 0; i < len(header); i++ {
+		char := header[i]
+
+		switch {
+		case quoted && char == '\\' && i+1 < len(header):
+			i++
+			token.WriteByte(header[i])
+		case char == '"':
+			quoted = !quoted
+		case quoted:
+			token.WriteByte(char)
+		case char == '=' && !hasKey:
+			key, hasKey = strings.TrimSpace(token.String()), true
+			token.Reset()
+		case char == ';' || char == ',':
+			if err := endPair(); err != nil {
+				return nil, err
+			}
+
+			if char == ',' && len(element) > 0 {
+				elements = append(elements, element)
+				element = nil
+			}
+		default:
+			token.WriteByte(char)
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidXFCC)
+	}
+
+	if err := endPair(); err != nil {
+		return nil, err
+	}
+
+	if len(element) > 0 {
+		elements = append(elements, element)
+	}
+
+	return elements, nil
+}
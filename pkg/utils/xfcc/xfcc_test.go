@@ -0,0 +1,104 @@
+package xfcc_test
+
+import (
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/utils/xfcc"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	formatted := xfcc.Format([]xfcc.Element{
+		{{Key: "By", Value: "spiffe://gateway"}, {Key: "Hash", Value: "abc"}},
+		{
+			{Key: "Hash", Value: "def"},
+			{Key: "Subject", Value: `CN=client,O="Acme; Inc"`},
+			{Key: "URI", Value: "spiffe://client"},
+			{Key: "DNS", Value: "a.example.com"},
+			{Key: "DNS", Value: "b.example.com"},
+		},
+	})
+
+	assert.Equal(t,
+		`By=spiffe://gateway;Hash=abc,Hash=def;Subject="CN=client,O=\"Acme; Inc\"";URI=spiffe://client;DNS=a.example.com;DNS=b.example.com`,
+		formatted)
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		header   string
+		expected []xfcc.Element
+		wantErr  bool
+	}{
+		{
+			name:   "Parse envoy example",
+			header: `By=http://frontend.lyft.com;Hash=468ed33be74eee6556d90c0149c1309e9ba61d6425303443c0748a02dd8de688;Subject="/C=US/ST=CA/L=San Francisco/OU=Lyft/CN=Test Client";URI=http://testclient.lyft.com`,
+			expected: []xfcc.Element{{
+				{Key: "By", Value: "http://frontend.lyft.com"},
+				{Key: "Hash", Value: "468ed33be74eee6556d90c0149c1309e9ba61d6425303443c0748a02dd8de688"},
+				{Key: "Subject", Value: "/C=US/ST=CA/L=San Francisco/OU=Lyft/CN=Test Client"},
+				{Key: "URI", Value: "http://testclient.lyft.com"},
+			}},
+		},
+		{
+			name:   "Parse several elements",
+			header: `By=spiffe://a;Hash=1,By=spiffe://b;Hash=2;Subject="CN=c,O=\"d\""`,
+			expected: []xfcc.Element{
+				{{Key: "By", Value: "spiffe://a"}, {Key: "Hash", Value: "1"}},
+				{{Key: "By", Value: "spiffe://b"}, {Key: "Hash", Value: "2"}, {Key: "Subject", Value: `CN=c,O="d"`}},
+			},
+		},
+		{
+			name:     "Parse empty header",
+			header:   "",
+			expected: nil,
+		},
+		{
+			name:    "Parse unterminated quote",
+			header:  `Subject="CN=c`,
+			wantErr: true,
+		},
+		{
+			name:    "Parse pair without key",
+			header:  `Hash=1;garbage`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			elements, err := xfcc.Parse(test.header)
+			if test.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, elements)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	elements := []xfcc.Element{{
+		{Key: "Hash", Value: "abc"},
+		{Key: "Subject", Value: `CN=a\b,O="c"`},
+		{Key: "Cert", Value: "-----BEGIN%20CERTIFICATE-----"},
+	}}
+
+	parsed, err := xfcc.Parse(xfcc.Format(elements))
+	require.NoError(t, err)
+	assert.Equal(t, elements, parsed)
+	assert.Equal(t, []string{"abc"}, parsed[0].Values("hash"))
+}
@@ -0,0 +1,123 @@
+package value
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/utils/watch"
+)
+
+// fileCheckInterval is how often referenced files are checked for changes.
+const fileCheckInterval = time.Second
+
+var errEmptyFile = errors.New("empty file")
+
+// referencePattern matches the ${env:NAME} and ${file:PATH} references.
+var referencePattern = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+
+// references is a value made of literal parts and references. Environment
+// variables are read once, and files again whenever they change.
+type references []Value
+
+func newReferences(raw string) (references, error) {
+	var (
+		parts references
+		last  int
+	)
+
+	for _, match := range referencePattern.FindAllStringSubmatchIndex(raw, -1) {
+		if match[0] > last {
+			parts = append(parts, literal(raw[last:match[0]]))
+		}
+
+		kind, name := raw[match[2]:match[3]], raw[match[4]:match[5]]
+
+		part, err := newReference(kind, name)
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, part)
+		last = match[1]
+	}
+
+	if last < len(raw) {
+		parts = append(parts, literal(raw[last:]))
+	}
+
+	return parts, nil
+}
+
+func newReference(kind, name string) (Value, error) {
+	if kind == "env" {
+		env, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: environment variable %s is not set", types.ErrMissingReference, name)
+		}
+
+		return literal(env), nil
+	}
+
+	return NewFile(name)
+}
+
+// NewReferences compiles raw as a literal holding references, ignoring templates
+// and header references. Its Resolve method can be called without a request.
+func NewReferences(raw string) (Value, error) {
+	if !referencePattern.MatchString(raw) {
+		return literal(raw), nil
+	}
+
+	return newReferences(raw)
+}
+
+// NewFile returns the content of the file at path, read again whenever it changes.
+// Surrounding whitespace of the file, such as a trailing newline, is ignored.
+// An empty file is rejected, so that the last content is kept while a rotated
+// file is being written. Its Resolve method can be called without a request.
+func NewFile(path string) (Value, error) {
+	file, err := watch.New(path, fileCheckInterval, func(content []byte) (interface{}, error) {
+		trimmed := strings.TrimSpace(string(content))
+		if trimmed == "" {
+			return nil, errEmptyFile
+		}
+
+		return trimmed, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", types.ErrMissingReference, err)
+	}
+
+	return fileRef{file: file}, nil
+}
+
+func (r references) Resolve(rw http.ResponseWriter, req *http.Request) (string, bool) {
+	var resolved strings.Builder
+	for _, part := range r {
+		value, ok := part.Resolve(rw, req)
+		if !ok {
+			return "", false
+		}
+
+		resolved.WriteString(value)
+	}
+
+	return resolved.String(), true
+}
+
+// fileRef is the content of a file.
+type fileRef struct {
+	file *watch.File
+}
+
+func (f fileRef) Resolve(_ http.ResponseWriter, _ *http.Request) (string, bool) {
+	content, _ := f.file.Value().(string)
+
+	return content, true
+}
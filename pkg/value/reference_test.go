@@ -0,0 +1,122 @@
+package value_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/value"
+)
+
+func TestReferences(t *testing.T) {
+	t.Setenv("HTRANSFORMATION_TEST_USER", "gateway")
+
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte("s3cr3t\n"), 0o600))
+
+	testCases := []struct {
+		name     string
+		raw      string
+		expected string
+	}{
+		{
+			name:     "environment variable",
+			raw:      "${env:HTRANSFORMATION_TEST_USER}",
+			expected: "gateway",
+		},
+		{
+			name:     "file",
+			raw:      "${file:" + path + "}",
+			expected: "s3cr3t",
+		},
+		{
+			name:     "references in a literal",
+			raw:      "Basic ${env:HTRANSFORMATION_TEST_USER}:${file:" + path + "}!",
+			expected: "Basic gateway:s3cr3t!",
+		},
+		{
+			name:     "unknown reference kind",
+			raw:      "${vault:secret/key}",
+			expected: "${vault:secret/key}",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			compiled, err := value.New(test.raw, "")
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			resolved, _ := compiled.Resolve(nil, req)
+			assert.Equal(t, test.expected, resolved)
+		})
+	}
+}
+
+func TestMissingReferences(t *testing.T) {
+	t.Parallel()
+
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	require.NoError(t, os.WriteFile(emptyFile, []byte("\n"), 0o600))
+
+	testCases := []struct {
+		name string
+		raw  string
+	}{
+		{
+			name: "missing environment variable",
+			raw:  "${env:HTRANSFORMATION_TEST_MISSING}",
+		},
+		{
+			name: "missing file",
+			raw:  "Bearer ${file:" + filepath.Join(t.TempDir(), "missing") + "}",
+		},
+		{
+			name: "empty file",
+			raw:  "Bearer ${file:" + emptyFile + "}",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := value.New(test.raw, "")
+			assert.Equal(t, true, errors.Is(err, types.ErrMissingReference))
+		})
+	}
+}
+
+func TestFileRotation(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0o600))
+
+	compiled, err := value.NewFile(path)
+	require.NoError(t, err)
+
+	// A file truncated before being written again keeps its last content.
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	time.Sleep(1100 * time.Millisecond)
+
+	resolved, _ := compiled.Resolve(nil, nil)
+	assert.Equal(t, "first", resolved)
+
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.WriteFile(path, []byte("second\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+	time.Sleep(1100 * time.Millisecond)
+
+	resolved, _ = compiled.Resolve(nil, nil)
+	assert.Equal(t, "second", resolved)
+}
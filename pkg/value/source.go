@@ -0,0 +1,53 @@
+package value
+
+import (
+	"net/http"
+
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+)
+
+// Source is a header read by a rule, such as the From header of Map and
+// Extract rules. Like header references, it can be in the req:, resp: and
+// original: namespaces, a name without namespace being read from the request.
+type Source struct {
+	name string
+	// response is set for response headers.
+	response bool
+}
+
+// NewSource returns the source header name.
+func NewSource(name string) Source {
+	ref := newHeaderRef(name)
+
+	return Source{name: ref.name, response: ref.response}
+}
+
+// Response reports whether the source is a response header, which is only
+// available to response rules.
+func (s Source) Response() bool {
+	return s.response
+}
+
+// Lookup returns the first value of the source header, or false when it is missing.
+func (s Source) Lookup(rw http.ResponseWriter, req *http.Request) (string, bool) {
+	if s.response {
+		return lookupResponseHeader(rw, s.name)
+	}
+
+	return header.Get(req, s.name)
+}
+
+// Delete removes the source header. An original: header is removed from the
+// request, the headers received by the middleware being left unchanged.
+func (s Source) Delete(rw http.ResponseWriter, req *http.Request) {
+	if s.response {
+		if rw != nil {
+			rw.Header().Del(s.name)
+		}
+
+		return
+	}
+
+	name, _ := trimPrefixFold(s.name, header.OriginalPrefix)
+	header.Delete(req, name)
+}
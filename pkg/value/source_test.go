@@ -0,0 +1,97 @@
+package value_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/value"
+)
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		source           string
+		expected         string
+		expectedFound    bool
+		expectedResponse bool
+		// deleted is the header expected to be removed, from the response when expectedResponse is set.
+		deleted string
+	}{
+		{
+			name:          "request header",
+			source:        "X-Region",
+			expected:      "request",
+			expectedFound: true,
+			deleted:       "X-Region",
+		},
+		{
+			name:             "response header",
+			source:           "resp:X-Region",
+			expected:         "response",
+			expectedFound:    true,
+			expectedResponse: true,
+			deleted:          "X-Region",
+		},
+		{
+			name:          "request header namespace",
+			source:        "REQ:X-Region",
+			expected:      "request",
+			expectedFound: true,
+			deleted:       "X-Region",
+		},
+		{
+			name:          "original header",
+			source:        "original:X-Region",
+			expected:      "original",
+			expectedFound: true,
+			deleted:       "X-Region",
+		},
+		{
+			name:             "missing header",
+			source:           "resp:X-Missing",
+			expected:         "",
+			expectedFound:    false,
+			expectedResponse: true,
+			deleted:          "X-Missing",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+			req.Header.Set("X-Region", "original")
+
+			state := &types.State{}
+			state.SaveOriginal(req)
+			req = types.WithState(req, state)
+			req.Header.Set("X-Region", "request")
+
+			recorder := httptest.NewRecorder()
+			recorder.Header().Set("X-Region", "response")
+
+			source := value.NewSource(test.source)
+			assert.Equal(t, test.expectedResponse, source.Response())
+
+			found, ok := source.Lookup(recorder, req)
+			assert.Equal(t, test.expectedFound, ok)
+			assert.Equal(t, test.expected, found)
+
+			source.Delete(recorder, req)
+
+			if test.expectedResponse {
+				assert.Equal(t, "", recorder.Header().Get(test.deleted))
+				assert.Equal(t, "request", req.Header.Get("X-Region"))
+			} else {
+				assert.Equal(t, "", req.Header.Get(test.deleted))
+				assert.Equal(t, "response", recorder.Header().Get("X-Region"))
+			}
+		})
+	}
+}
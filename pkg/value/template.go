@@ -0,0 +1,352 @@
+package value
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/tomMoulard/htransformation/pkg/types"
+)
+
+// tmpl is a value rendered from a Go text/template.
+type tmpl struct {
+	template *template.Template
+	// renderers holds clones of template, so that it is not cloned on every request.
+	renderers sync.Pool
+	// fields are the TemplateData fields costly to build that the template reads.
+	fields dataFields
+	// response is set when the template reads response headers.
+	response bool
+}
+
+// renderer renders a clone of a template, whose functions are bound to the
+// request and response it renders the template for.
+type renderer struct {
+	template *template.Template
+	rw       http.ResponseWriter
+	req      *http.Request
+	data     TemplateData
+	rendered bytes.Buffer
+}
+
+// dataFields is a set of TemplateData fields, which are only built when a
+// template reads them.
+type dataFields uint8
+
+const (
+	fieldQuery dataFields = 1 << iota
+	fieldUser
+	fieldCookies
+	fieldTLS
+
+	allFields = fieldQuery | fieldUser | fieldCookies | fieldTLS
+)
+
+// TemplateData is the data a value template is rendered with.
+type TemplateData struct {
+	Method     string
+	Scheme     string
+	Host       string
+	Path       string
+	RawQuery   string
+	Query      url.Values
+	RemoteAddr string
+	// User is the username of the request Basic credentials, if any.
+	User    string
+	Cookies map[string]string
+	Header  http.Header
+	// TLS is nil when the request was not received over TLS.
+	TLS *TLSData
+}
+
+// TLSData describes the TLS connection of a request.
+type TLSData struct {
+	Version     string
+	CipherSuite string
+	ServerName  string
+}
+
+func newTemplate(raw string) (*tmpl, error) {
+	// The functions are bound to the request when the template is rendered.
+	parsed, err := template.New("value").Option("missingkey=zero").Funcs(new(renderer).funcMap()).Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", types.ErrInvalidTemplate, raw, err)
+	}
+
+	value := &tmpl{template: parsed}
+	value.renderers.New = value.newRenderer
+
+	for _, defined := range parsed.Templates() {
+		if defined.Tree == nil {
+			continue
+		}
+
+		if readsResponse(defined.Tree.Root) {
+			value.response = true
+		}
+
+		// The dot of templates other than the main one may not be the data,
+		// which can only add fields that are not needed.
+		value.fields |= usedFields(defined.Tree.Root, true)
+	}
+
+	return value, nil
+}
+
+// newRenderer returns a renderer of a clone of the template, or nil if it
+// cannot be cloned.
+func (t *tmpl) newRenderer() interface{} {
+	clone, err := t.template.Clone()
+	if err != nil {
+		return nil
+	}
+
+	r := &renderer{}
+	r.template = clone.Funcs(r.funcMap())
+
+	return r
+}
+
+// usedFields returns the TemplateData fields costly to build that node reads,
+// dotIsData being set when the dot of node is the TemplateData. All of them are
+// returned when the data itself is used, such as in {{ template "name" . }}.
+func usedFields(node parse.Node, dotIsData bool) dataFields {
+	switch node := node.(type) {
+	case *parse.FieldNode:
+		if !dotIsData {
+			return 0
+		}
+
+		return fieldNamed(node.Ident[0])
+	case *parse.DotNode:
+		if !dotIsData {
+			return 0
+		}
+
+		return allFields
+	case *parse.VariableNode:
+		// $ is always the data, other variables being set from pipelines.
+		if node.Ident[0] != "$" {
+			return 0
+		}
+
+		if len(node.Ident) == 1 {
+			return allFields
+		}
+
+		return fieldNamed(node.Ident[1])
+	case *parse.ChainNode:
+		return usedFields(node.Node, dotIsData)
+	case *parse.WithNode:
+		return branchFields(&node.BranchNode, dotIsData)
+	case *parse.RangeNode:
+		return branchFields(&node.BranchNode, dotIsData)
+	}
+
+	var fields dataFields
+	for _, child := range children(node) {
+		fields |= usedFields(child, dotIsData)
+	}
+
+	return fields
+}
+
+// branchFields returns the fields read by a with or range node, whose dot is
+// set to the value of its pipeline in its main branch.
+func branchFields(branch *parse.BranchNode, dotIsData bool) dataFields {
+	return usedFields(branch.Pipe, dotIsData) |
+		usedFields(branch.List, false) |
+		usedFields(branch.ElseList, dotIsData)
+}
+
+func fieldNamed(name string) dataFields {
+	switch name {
+	case "Query":
+		return fieldQuery
+	case "User":
+		return fieldUser
+	case "Cookies":
+		return fieldCookies
+	case "TLS":
+		return fieldTLS
+	default:
+		return 0
+	}
+}
+
+// readsResponse reports whether node calls the header function with a literal
+// name in the resp: namespace, such as {{ header "resp:ETag" }}.
+func readsResponse(node parse.Node) bool {
+	if command, ok := node.(*parse.CommandNode); ok && isResponseHeaderCall(command) {
+		return true
+	}
+
+	for _, child := range children(node) {
+		if readsResponse(child) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isResponseHeaderCall(command *parse.CommandNode) bool {
+	if len(command.Args) == 0 {
+		return false
+	}
+
+	if function, ok := command.Args[0].(*parse.IdentifierNode); !ok || function.Ident != "header" {
+		return false
+	}
+
+	for _, arg := range command.Args[1:] {
+		if name, ok := arg.(*parse.StringNode); ok && IsResponseHeader(name.Text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// children returns the nodes of a template tree under node.
+func children(node parse.Node) []parse.Node {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return nil
+		}
+
+		return node.Nodes
+	case *parse.ActionNode:
+		return []parse.Node{node.Pipe}
+	case *parse.IfNode:
+		return branchChildren(&node.BranchNode)
+	case *parse.RangeNode:
+		return branchChildren(&node.BranchNode)
+	case *parse.WithNode:
+		return branchChildren(&node.BranchNode)
+	case *parse.TemplateNode:
+		return []parse.Node{node.Pipe}
+	case *parse.PipeNode:
+		if node == nil {
+			return nil
+		}
+
+		nodes := make([]parse.Node, 0, len(node.Cmds))
+		for _, command := range node.Cmds {
+			nodes = append(nodes, command)
+		}
+
+		return nodes
+	case *parse.CommandNode:
+		return node.Args
+	}
+
+	return nil
+}
+
+func branchChildren(branch *parse.BranchNode) []parse.Node {
+	return []parse.Node{branch.Pipe, branch.List, branch.ElseList}
+}
+
+// Resolve renders the template, or returns false if it fails.
+func (t *tmpl) Resolve(rw http.ResponseWriter, req *http.Request) (string, bool) {
+	r, ok := t.renderers.Get().(*renderer)
+	if !ok {
+		return "", false
+	}
+
+	defer t.renderers.Put(r)
+
+	r.rw, r.req = rw, req
+	r.data = newTemplateData(req, t.fields)
+
+	err := r.template.Execute(&r.rendered, &r.data)
+	rendered := r.rendered.String()
+
+	// The request is not kept alive by the renderer waiting in the pool.
+	r.rw = nil
+	r.req = nil
+	r.data = TemplateData{}
+	r.rendered.Reset()
+
+	if err != nil {
+		return "", false
+	}
+
+	return rendered, true
+}
+
+// funcMap returns the functions of value templates, bound to the request and
+// response of r.
+func (r *renderer) funcMap() template.FuncMap {
+	return template.FuncMap{
+		"header": func(name string) string {
+			return Header(r.rw, r.req, name)
+		},
+		"query": func(name string) string {
+			return r.req.URL.Query().Get(name)
+		},
+		"cookie": func(name string) string {
+			cookie, err := r.req.Cookie(name)
+			if err != nil {
+				return ""
+			}
+
+			return cookie.Value
+		},
+	}
+}
+
+// newTemplateData returns the data of req, with only the given fields among
+// the ones costly to build.
+func newTemplateData(req *http.Request, fields dataFields) TemplateData {
+	data := TemplateData{
+		Method:     req.Method,
+		Scheme:     "http",
+		Host:       req.Host,
+		Path:       req.URL.Path,
+		RawQuery:   req.URL.RawQuery,
+		RemoteAddr: req.RemoteAddr,
+		Header:     req.Header,
+	}
+
+	if fields&fieldQuery != 0 {
+		data.Query = req.URL.Query()
+	}
+
+	if fields&fieldUser != 0 {
+		if user, _, ok := req.BasicAuth(); ok {
+			data.User = user
+		}
+	}
+
+	if fields&fieldCookies != 0 {
+		data.Cookies = make(map[string]string)
+
+		for _, cookie := range req.Cookies() {
+			if _, ok := data.Cookies[cookie.Name]; !ok {
+				data.Cookies[cookie.Name] = cookie.Value
+			}
+		}
+	}
+
+	if req.TLS != nil {
+		data.Scheme = "https"
+
+		if fields&fieldTLS != 0 {
+			data.TLS = &TLSData{
+				Version:     tls.VersionName(req.TLS.Version),
+				CipherSuite: tls.CipherSuiteName(req.TLS.CipherSuite),
+				ServerName:  req.TLS.ServerName,
+			}
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,211 @@
+package value_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/value"
+)
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		raw      string
+		tls      bool
+		expected string
+		failed   bool
+	}{
+		{
+			name:     "request line",
+			raw:      `{{ .Method }} {{ .Host }}{{ .Path }} from {{ header "X-Real-IP" }}`,
+			expected: "POST example.com/foo from 192.0.2.1",
+		},
+		{
+			name:     "query",
+			raw:      `{{ query "tenant" }}/{{ .Query.Get "page" }}/{{ .RawQuery }}`,
+			expected: "acme/2/tenant=acme&page=2",
+		},
+		{
+			name:     "cookies",
+			raw:      `{{ cookie "session" }}:{{ .Cookies.theme }}:{{ cookie "missing" }}`,
+			expected: "abc:dark:",
+		},
+		{
+			name:     "basic auth user",
+			raw:      `{{ .User }}`,
+			expected: "jane",
+		},
+		{
+			name:     "remote address and scheme",
+			raw:      `{{ .Scheme }}://{{ .RemoteAddr }}`,
+			expected: "http://192.0.2.1:1234",
+		},
+		{
+			name:     "headers",
+			raw:      `{{ index .Header "X-Real-Ip" 0 }}|{{ header "host" }}`,
+			expected: "192.0.2.1|example.com",
+		},
+		{
+			name:     "functions inside range",
+			raw:      `{{ range .Query.tenant }}{{ . }}@{{ header "X-Real-IP" }}{{ end }}`,
+			expected: "acme@192.0.2.1",
+		},
+		{
+			name:     "tls",
+			raw:      `{{ .Scheme }} {{ .TLS.Version }} {{ .TLS.ServerName }}`,
+			tls:      true,
+			expected: "https TLS 1.3 example.com",
+		},
+		{
+			name:     "conditional tls",
+			raw:      `{{ if .TLS }}secure{{ else }}plain{{ end }}`,
+			expected: "plain",
+		},
+		{
+			name:     "tls inside with",
+			raw:      `{{ with .TLS }}{{ .ServerName }}{{ end }}`,
+			tls:      true,
+			expected: "example.com",
+		},
+		{
+			name:     "data from a nested dot",
+			raw:      `{{ with .Method }}{{ . }} {{ $.Cookies.theme }}{{ end }}`,
+			expected: "POST dark",
+		},
+		{
+			name:     "data in a variable",
+			raw:      `{{ $data := . }}{{ with .Method }}{{ $data.User }}{{ end }}`,
+			expected: "jane",
+		},
+		{
+			name:     "data passed to a defined template",
+			raw:      `{{ define "user" }}{{ .User }}{{ end }}{{ template "user" . }}`,
+			expected: "jane",
+		},
+		{
+			name:   "execution error",
+			raw:    `{{ .TLS.Version }}`,
+			failed: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			compiled, err := value.New(test.raw, "")
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "http://example.com/foo?tenant=acme&page=2", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("X-Real-IP", "192.0.2.1")
+			req.Header.Set("Cookie", "session=abc; theme=dark")
+			req.SetBasicAuth("jane", "s3cr3t")
+
+			if test.tls {
+				req.TLS = &tls.ConnectionState{Version: tls.VersionTLS13, ServerName: "example.com"}
+			}
+
+			resolved, ok := compiled.Resolve(httptest.NewRecorder(), req)
+			assert.Equal(t, test.expected, resolved)
+			assert.Equal(t, !test.failed, ok)
+		})
+	}
+}
+
+func TestTemplateValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			raw:     `{{ header "X-Foo" }}`,
+			wantErr: false,
+		},
+		{
+			name:    "unterminated action",
+			raw:     `{{ .Method `,
+			wantErr: true,
+		},
+		{
+			name:    "unknown function",
+			raw:     `{{ env "HOME" }}`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := value.New(test.raw, "")
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTemplateConcurrentRequests(t *testing.T) {
+	t.Parallel()
+
+	compiled, err := value.New(`{{ .Path }}:{{ header "X-Id" }}:{{ query "id" }}`, "")
+	require.NoError(t, err)
+
+	results := make([]string, 50)
+
+	var wg sync.WaitGroup
+
+	for i := range results {
+		wg.Add(1)
+
+		// i is passed explicitly, as Yaegi shares loop variables between iterations.
+		go func(i int) {
+			defer wg.Done()
+
+			id := strconv.Itoa(i)
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/"+id+"?id="+id, nil)
+			req.Header.Set("X-Id", id)
+
+			results[i], _ = compiled.Resolve(nil, req)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i, result := range results {
+		id := strconv.Itoa(i)
+		assert.Equal(t, "/"+id+":"+id+":"+id, result)
+	}
+}
+
+func BenchmarkTemplate(b *testing.B) {
+	compiled, err := value.New(`{{ .Method }} {{ .Host }}{{ .Path }} from {{ header "X-Real-IP" }}`, "")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/foo?tenant=acme", nil)
+	req.Header.Set("X-Real-IP", "192.0.2.1")
+	req.Header.Set("Cookie", "session=abc; theme=dark")
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		compiled.Resolve(nil, req)
+	}
+}
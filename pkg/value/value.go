@@ -0,0 +1,192 @@
+// Package value compiles the values written by rules. A value is either a
+// literal, a reference to another header (using the rule HeaderPrefix, with
+// the req:, resp: and original: namespaces), a Go text/template rendered with
+// the request context, or a literal holding ${env:NAME} and ${file:PATH}
+// references.
+package value
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tomMoulard/htransformation/pkg/utils/header"
+)
+
+// Value is a rule value, computed for each request.
+type Value interface {
+	// Resolve returns the value, or false when it cannot be computed, such as
+	// a template failing to render, in which case nothing should be written.
+	Resolve(rw http.ResponseWriter, req *http.Request) (string, bool)
+}
+
+// New compiles raw. It is a template when it contains "{{", a list of
+// references when it contains some, a header reference when it starts with a
+// non-empty headerPrefix, and a literal otherwise.
+func New(raw, headerPrefix string) (Value, error) {
+	if strings.Contains(raw, "{{") {
+		return newTemplate(raw)
+	}
+
+	if referencePattern.MatchString(raw) {
+		return newReferences(raw)
+	}
+
+	return NewPlain(raw, headerPrefix), nil
+}
+
+// NewPlain compiles raw without templates nor references: it is a header
+// reference when it starts with a non-empty headerPrefix, and a literal
+// otherwise. Rules without Interpolate compile their values this way.
+func NewPlain(raw, headerPrefix string) Value {
+	if headerPrefix != "" && strings.HasPrefix(raw, headerPrefix) {
+		// If the resulting value after removing the prefix is empty,
+		// we use the actual value, which is the prefix itself.
+		// This is because doing a req.Header.Get("") would not fly well.
+		if name := strings.TrimPrefix(raw, headerPrefix); name != "" {
+			return newHeaderRef(name)
+		}
+	}
+
+	return literal(raw)
+}
+
+// NewList compiles a list of raw values, with New when interpolate is set and
+// with NewPlain otherwise.
+func NewList(raws []string, headerPrefix string, interpolate bool) ([]Value, error) {
+	values := make([]Value, 0, len(raws))
+
+	for _, raw := range raws {
+		if !interpolate {
+			values = append(values, NewPlain(raw, headerPrefix))
+
+			continue
+		}
+
+		compiled, err := New(raw, headerPrefix)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, compiled)
+	}
+
+	return values, nil
+}
+
+// NewLiteral returns raw as is, without templates nor references.
+func NewLiteral(raw string) Value {
+	return literal(raw)
+}
+
+type literal string
+
+func (l literal) Resolve(_ http.ResponseWriter, _ *http.Request) (string, bool) {
+	return string(l), true
+}
+
+// Namespaces of header references, such as ^resp:ETag. Header references
+// without namespace read the request headers.
+const (
+	RequestPrefix  = "req:"
+	ResponsePrefix = "resp:"
+)
+
+// headerRef is the value of another header.
+type headerRef struct {
+	name string
+	// response is set for references to response headers (resp:Name).
+	response bool
+}
+
+func newHeaderRef(name string) headerRef {
+	if trimmed, ok := trimPrefixFold(name, ResponsePrefix); ok {
+		return headerRef{name: trimmed, response: true}
+	}
+
+	if trimmed, ok := trimPrefixFold(name, RequestPrefix); ok {
+		return headerRef{name: trimmed}
+	}
+
+	return headerRef{name: name}
+}
+
+func (h headerRef) Resolve(rw http.ResponseWriter, req *http.Request) (string, bool) {
+	if h.response {
+		return responseHeader(rw, h.name), true
+	}
+
+	return RequestHeader(req, h.name), true
+}
+
+// ReferencesResponse reports whether the value of v is read from the response
+// headers, which are only available to response rules.
+func ReferencesResponse(v Value) bool {
+	switch v := v.(type) {
+	case headerRef:
+		return v.response
+	case *tmpl:
+		return v.response
+	default:
+		return false
+	}
+}
+
+// IsResponseHeader reports whether name is in the resp: namespace.
+func IsResponseHeader(name string) bool {
+	_, ok := trimPrefixFold(name, ResponsePrefix)
+
+	return ok
+}
+
+// Header returns the first value of a header, read from the response when
+// name is in the resp: namespace, and from the request otherwise.
+func Header(rw http.ResponseWriter, req *http.Request, name string) string {
+	if trimmed, ok := trimPrefixFold(name, ResponsePrefix); ok {
+		return responseHeader(rw, trimmed)
+	}
+
+	if trimmed, ok := trimPrefixFold(name, RequestPrefix); ok {
+		name = trimmed
+	}
+
+	return RequestHeader(req, name)
+}
+
+// responseHeader returns the first value of a response header, or an empty
+// string when there is no response.
+func responseHeader(rw http.ResponseWriter, name string) string {
+	value, _ := lookupResponseHeader(rw, name)
+
+	return value
+}
+
+// lookupResponseHeader returns the first value of a response header, or false
+// when it is missing or there is no response.
+func lookupResponseHeader(rw http.ResponseWriter, name string) (string, bool) {
+	if rw == nil {
+		return "", false
+	}
+
+	values := rw.Header().Values(name)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[0], true
+}
+
+// RequestHeader returns the first value of a request header, Host being read
+// from req.Host, and original:Name from the headers received by the middleware.
+func RequestHeader(req *http.Request, name string) string {
+	value, _ := header.Get(req, name)
+
+	return value
+}
+
+func trimPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+
+	return s[len(prefix):], true
+}
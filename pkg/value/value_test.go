@@ -0,0 +1,201 @@
+package value_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tomMoulard/htransformation/pkg/tests/assert"
+	"github.com/tomMoulard/htransformation/pkg/tests/require"
+	"github.com/tomMoulard/htransformation/pkg/types"
+	"github.com/tomMoulard/htransformation/pkg/value"
+)
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		raw          string
+		headerPrefix string
+		expected     string
+	}{
+		{
+			name:     "literal",
+			raw:      "Foo",
+			expected: "Foo",
+		},
+		{
+			name:         "literal without prefix",
+			raw:          "X-Source",
+			headerPrefix: "^",
+			expected:     "X-Source",
+		},
+		{
+			name:         "header reference",
+			raw:          "^X-Source",
+			headerPrefix: "^",
+			expected:     "Tested",
+		},
+		{
+			name:         "host reference",
+			raw:          "^host",
+			headerPrefix: "^",
+			expected:     "example.com",
+		},
+		{
+			name:         "missing header reference",
+			raw:          "^X-Missing",
+			headerPrefix: "^",
+			expected:     "",
+		},
+		{
+			name:         "original header reference",
+			raw:          "^original:X-Received",
+			headerPrefix: "^",
+			expected:     "Received",
+		},
+		{
+			name:         "header reference removed by a rule",
+			raw:          "^X-Received",
+			headerPrefix: "^",
+			expected:     "",
+		},
+		{
+			name:         "original host reference",
+			raw:          "^original:Host",
+			headerPrefix: "^",
+			expected:     "example.com",
+		},
+		{
+			name:         "prefix only",
+			raw:          "^",
+			headerPrefix: "^",
+			expected:     "^",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			compiled, err := value.New(test.raw, test.headerPrefix)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+			req.Header.Set("X-Source", "Tested")
+			req.Header.Set("X-Received", "Received")
+
+			state := &types.State{}
+			state.SaveOriginal(req)
+			req = types.WithState(req, state)
+
+			req.Header.Del("X-Received")
+
+			resolved, _ := compiled.Resolve(httptest.NewRecorder(), req)
+			assert.Equal(t, test.expected, resolved)
+		})
+	}
+}
+
+func TestHeaderNamespaces(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		raw      string
+		expected string
+		response bool
+	}{
+		{
+			name:     "request header",
+			raw:      "^X-Source",
+			expected: "request",
+		},
+		{
+			name:     "explicit request header",
+			raw:      "^req:X-Source",
+			expected: "request",
+		},
+		{
+			name:     "request host",
+			raw:      "^REQ:Host",
+			expected: "example.com",
+		},
+		{
+			name:     "response header",
+			raw:      "^resp:X-Source",
+			expected: "response",
+			response: true,
+		},
+		{
+			name:     "response header in template",
+			raw:      `{{ header "resp:ETag" }}/{{ header "req:X-Source" }}`,
+			expected: `"v1"/request`,
+			response: true,
+		},
+		{
+			name:     "response header in template block",
+			raw:      `{{ with .Method }}{{ if eq (header "Resp:X-Source") "response" }}{{ . }}{{ end }}{{ end }}`,
+			expected: "GET",
+			response: true,
+		},
+		{
+			name:     "request header in template",
+			raw:      `{{ header "X-Source" }}/{{ header "original:X-Source" }}`,
+			expected: "request/request",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			compiled, err := value.New(test.raw, "^")
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+			req.Header.Set("X-Source", "request")
+
+			recorder := httptest.NewRecorder()
+			recorder.Header().Set("X-Source", "response")
+			recorder.Header().Set("ETag", `"v1"`)
+
+			resolved, _ := compiled.Resolve(recorder, req)
+			assert.Equal(t, test.expected, resolved)
+			assert.Equal(t, test.response, value.ReferencesResponse(compiled))
+		})
+	}
+}
+
+func TestNewList(t *testing.T) {
+	t.Parallel()
+
+	values, err := value.NewList([]string{"Foo", "^X-Source", "{{ .Method }}"}, "^", true)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/foo", nil)
+	req.Header.Set("X-Source", "Tested")
+
+	resolved := make([]string, 0, len(values))
+	for _, v := range values {
+		item, _ := v.Resolve(nil, req)
+		resolved = append(resolved, item)
+	}
+
+	assert.Equal(t, []string{"Foo", "Tested", "GET"}, resolved)
+
+	_, err = value.NewList([]string{"Foo", "{{ .Method "}, "^", true)
+	assert.Error(t, err)
+
+	values, err = value.NewList([]string{"^X-Source", "{{ .Method ", "${env:NOPE}"}, "^", false)
+	require.NoError(t, err)
+
+	resolved = resolved[:0]
+	for _, v := range values {
+		item, _ := v.Resolve(nil, req)
+		resolved = append(resolved, item)
+	}
+
+	assert.Equal(t, []string{"Tested", "{{ .Method ", "${env:NOPE}"}, resolved)
+}